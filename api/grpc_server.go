@@ -0,0 +1,416 @@
+package api
+
+import (
+	"context"
+	"crawlweb/api/pb"
+	"crawlweb/infrastructure"
+	"crawlweb/model"
+	"crawlweb/service"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"log"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// STREAM_MAX_URLS max urls of one StreamPreviews request
+	STREAM_MAX_URLS            = 1000
+	STREAM_DEFAULT_CONCURRENCY = 8
+	STREAM_MAX_CONCURRENCY     = 32
+	// S3_MAX_PARTS max number of parts of multipart upload
+	S3_MAX_PARTS = 10000
+	// PRESIGNED_URL_EXPIRES expiry of url of GetPresignedUrlUploadFile
+	PRESIGNED_URL_EXPIRES = 5 * time.Minute
+)
+
+type crawlServer struct {
+	pb.UnimplementedCrawlServiceServer
+}
+
+type fileServer struct {
+	pb.UnimplementedFileServiceServer
+}
+
+// NewGrpcServer grpc server with crawl, file, health and reflection services.
+// When token is not empty, every rpc except health check needs metadata "authorization: Bearer <token>"
+func NewGrpcServer(token string) (*grpc.Server, *health.Server) {
+	auth := tokenAuth{token: token}
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(unaryRecoverInterceptor, auth.unaryInterceptor),
+		grpc.ChainStreamInterceptor(streamRecoverInterceptor, auth.streamInterceptor),
+	)
+	pb.RegisterCrawlServiceServer(server, &crawlServer{})
+	pb.RegisterFileServiceServer(server, &fileServer{})
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(server, healthServer)
+	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	for name := range server.GetServiceInfo() {
+		healthServer.SetServingStatus(name, healthpb.HealthCheckResponse_SERVING)
+	}
+	reflection.Register(server)
+	return server, healthServer
+}
+
+func (s *crawlServer) GetPreview(ctx context.Context, req *pb.GetPreviewRequest) (*pb.Preview, error) {
+	if req.GetUrl() == "" {
+		return nil, status.Error(codes.InvalidArgument, "url is required")
+	}
+	if err := validateUrl(req.GetUrl()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	ctx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	defer cancel()
+	openGraphModel, cached, err := service.GetPreview(ctx, req.GetUrl(), service.CrawlOptions{SkipUpload: req.GetSkipUpload()})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	preview := toPreview(openGraphModel)
+	preview.Cached = cached
+	return preview, nil
+}
+
+func (s *crawlServer) StreamPreviews(req *pb.StreamPreviewsRequest, stream grpc.ServerStreamingServer[pb.PreviewResult]) error {
+	urls := req.GetUrls()
+	if len(urls) == 0 {
+		return status.Error(codes.InvalidArgument, "urls is required")
+	}
+	if len(urls) > STREAM_MAX_URLS {
+		return status.Errorf(codes.InvalidArgument, "too many urls, max is %d", STREAM_MAX_URLS)
+	}
+	concurrency := int(req.GetConcurrency())
+	if concurrency <= 0 {
+		concurrency = STREAM_DEFAULT_CONCURRENCY
+	}
+	if concurrency > STREAM_MAX_CONCURRENCY {
+		concurrency = STREAM_MAX_CONCURRENCY
+	}
+	ctx := stream.Context()
+	options := service.CrawlOptions{SkipUpload: req.GetSkipUpload()}
+
+	indexes := make(chan int)
+	results := make(chan *pb.PreviewResult)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				results <- previewResult(ctx, index, urls[index], options)
+			}
+		}()
+	}
+	go func() {
+		defer close(indexes)
+		for index := range urls {
+			select {
+			case indexes <- index:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// results are sent by this goroutine only, stream.Send is not safe for concurrent use
+	var errSend error
+	for result := range results {
+		if errSend != nil {
+			continue
+		}
+		errSend = stream.Send(result)
+	}
+	if errSend != nil {
+		return errSend
+	}
+	return ctx.Err()
+}
+
+func previewResult(ctx context.Context, index int, url string, options service.CrawlOptions) *pb.PreviewResult {
+	start := time.Now()
+	result := &pb.PreviewResult{Index: int32(index), Url: url}
+	if err := validateUrl(url); err != nil {
+		result.ErrorCode, result.Error = "invalid_url", err.Error()
+		return result
+	}
+	ctx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	defer cancel()
+	openGraphModel, cached, err := service.GetPreview(ctx, url, options)
+	result.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		_, result.ErrorCode = errorStatus(err)
+		result.Error = err.Error()
+		return result
+	}
+	result.Preview = toPreview(openGraphModel)
+	result.Preview.Cached = cached
+	return result
+}
+
+func (s *fileServer) GetPresignedUploadUrl(ctx context.Context, req *pb.GetPresignedUploadUrlRequest) (*pb.GetPresignedUploadUrlResponse, error) {
+	key := newUploadKey(req.GetFileName())
+	url := service.GetPresignedUrlUploadFile(infrastructure.GetBucketName(), key)
+	if url == "" {
+		return nil, status.Error(codes.Internal, "generate presigned url fail")
+	}
+	return &pb.GetPresignedUploadUrlResponse{Key: key, Url: url, ExpiresInSeconds: int64(PRESIGNED_URL_EXPIRES.Seconds())}, nil
+}
+
+func (s *fileServer) GetPresignedMultipartUploadUrls(ctx context.Context, req *pb.GetPresignedMultipartUploadUrlsRequest) (*pb.GetPresignedMultipartUploadUrlsResponse, error) {
+	if req.GetFileSize() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "file_size must be positive")
+	}
+	partSize := req.GetPartSize()
+	if partSize == 0 {
+		partSize = service.PART_SIZE
+	}
+	if partSize < service.PART_SIZE {
+		return nil, status.Errorf(codes.InvalidArgument, "part_size must be at least %d", service.PART_SIZE)
+	}
+	if ceilDiv(req.GetFileSize(), partSize) > S3_MAX_PARTS {
+		return nil, status.Errorf(codes.InvalidArgument, "too many parts, part_size must be at least %d", ceilDiv(req.GetFileSize(), S3_MAX_PARTS))
+	}
+	key := newUploadKey(req.GetFileName())
+	uploadId, parts, err := service.GetPresignedUrlUploadLargeFile(infrastructure.GetBucketName(), key, req.GetFileSize(), int(partSize))
+	if err != nil {
+		log.Println("presign multipart upload error:", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	res := &pb.GetPresignedMultipartUploadUrlsResponse{Key: key, UploadId: uploadId}
+	for _, part := range parts {
+		res.Parts = append(res.Parts, &pb.PresignedPart{PartNumber: int32(part.PartNumber), Url: part.Url})
+	}
+	return res, nil
+}
+
+func (s *fileServer) CompleteMultipartUpload(ctx context.Context, req *pb.CompleteMultipartUploadRequest) (*pb.CompleteMultipartUploadResponse, error) {
+	if req.GetKey() == "" || req.GetUploadId() == "" || len(req.GetParts()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "key, upload_id and parts are required")
+	}
+	completedParts := make([]*s3.CompletedPart, 0, len(req.GetParts()))
+	for _, part := range req.GetParts() {
+		completedParts = append(completedParts, &s3.CompletedPart{
+			ETag:       aws.String(part.GetEtag()),
+			PartNumber: aws.Int64(int64(part.GetPartNumber())),
+		})
+	}
+	etag, err := service.CompleteMultipartUpload(infrastructure.GetBucketName(), req.GetKey(), req.GetUploadId(), completedParts)
+	event := model.UploadEventData{Source: "multipart:" + req.GetUploadId(), Storage: service.STORAGE_S3, Filename: req.GetKey(), Etag: etag}
+	if err != nil {
+		event.Error = err.Error()
+		notifyWebhook(service.WEBHOOK_UPLOAD_FAILED, event)
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	if _, err := service.RecordBucketUpload(ctx, req.GetKey()); err != nil {
+		log.Println("record multipart upload error:", req.GetKey(), err)
+	}
+	notifyWebhook(service.WEBHOOK_UPLOAD_COMPLETED, event)
+	return &pb.CompleteMultipartUploadResponse{Key: req.GetKey(), Etag: etag}, nil
+}
+
+func (s *fileServer) CompleteUpload(ctx context.Context, req *pb.CompleteUploadRequest) (*pb.FileInfo, error) {
+	if req.GetKey() == "" {
+		return nil, status.Error(codes.InvalidArgument, "key is required")
+	}
+	info, err := service.RecordBucketUpload(ctx, req.GetKey())
+	if errors.Is(err, service.ErrObjectNotFound) {
+		return nil, status.Errorf(codes.NotFound, "file %s is not uploaded", req.GetKey())
+	}
+	if err != nil {
+		log.Println("record upload error:", req.GetKey(), err)
+		return nil, status.Error(codes.Internal, "record upload fail")
+	}
+	notifyWebhook(service.WEBHOOK_UPLOAD_COMPLETED, model.UploadEventData{Source: "presigned:" + req.GetKey(), Storage: service.STORAGE_S3, Filename: req.GetKey()})
+	return toFileInfo(info), nil
+}
+
+func (s *fileServer) AbortMultipartUpload(ctx context.Context, req *pb.AbortMultipartUploadRequest) (*pb.AbortMultipartUploadResponse, error) {
+	if req.GetKey() == "" || req.GetUploadId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "key and upload_id are required")
+	}
+	if err := service.AbortMultipartUpload(infrastructure.GetBucketName(), req.GetKey(), req.GetUploadId()); err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return &pb.AbortMultipartUploadResponse{}, nil
+}
+
+func (s *fileServer) GetFileInfo(ctx context.Context, req *pb.GetFileInfoRequest) (*pb.FileInfo, error) {
+	if req.GetFileName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "file_name is required")
+	}
+	info, err := service.GetFileUploadInfo(ctx, req.GetFileName())
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, status.Errorf(codes.NotFound, "file %s not found", req.GetFileName())
+	}
+	if err != nil {
+		log.Println("get file info error:", err)
+		return nil, status.Error(codes.Internal, "get file info fail")
+	}
+	return toFileInfo(info), nil
+}
+
+// notifyWebhook emit event in background, response of rpc does not wait for webhook deliveries
+func notifyWebhook(eventType string, data interface{}) {
+	go func() {
+		if err := service.EmitWebhookEvent(context.Background(), eventType, data); err != nil {
+			log.Println("webhook", eventType, "error:", err)
+		}
+	}()
+}
+
+// newUploadKey random key of bucket with extension of file name
+func newUploadKey(fileName string) string {
+	return service.GenCode() + strings.ToLower(filepath.Ext(fileName))
+}
+
+// grpcError grpc status of crawl error, code of HTTP API is kept in message
+func grpcError(err error) error {
+	_, code := errorStatus(err)
+	grpcCode := codes.Unavailable
+	switch code {
+	case "timeout":
+		grpcCode = codes.DeadlineExceeded
+	case "canceled":
+		grpcCode = codes.Canceled
+	case "url_not_allowed":
+		grpcCode = codes.PermissionDenied
+	case "content_type_not_allowed", "body_too_large":
+		grpcCode = codes.FailedPrecondition
+	}
+	return status.Error(grpcCode, code+": "+err.Error())
+}
+
+// ceilDiv a / b rounded up
+func ceilDiv(a int64, b int64) int64 {
+	return (a + b - 1) / b
+}
+
+// tokenAuth check bearer token of metadata, empty token allow all
+type tokenAuth struct {
+	token string
+}
+
+func (a tokenAuth) check(ctx context.Context, fullMethod string) error {
+	if a.token == "" || strings.HasPrefix(fullMethod, "/grpc.health.v1.Health/") {
+		return nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, value := range md.Get("authorization") {
+		token := strings.TrimSpace(strings.TrimPrefix(value, "Bearer "))
+		if subtle.ConstantTimeCompare([]byte(token), []byte(a.token)) == 1 {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "invalid or missing bearer token")
+}
+
+func (a tokenAuth) unaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if err := a.check(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a tokenAuth) streamInterceptor(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := a.check(stream.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, stream)
+}
+
+func streamRecoverInterceptor(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("panic in %s: %v", info.FullMethod, r)
+			err = status.Error(codes.Internal, "internal server error")
+		}
+	}()
+	return handler(srv, stream)
+}
+
+func unaryRecoverInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (res any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("panic in %s: %v", info.FullMethod, r)
+			err = status.Error(codes.Internal, "internal server error")
+		}
+	}()
+	return handler(ctx, req)
+}
+
+func toPreview(openGraphModel model.OpenGraphModel) *pb.Preview {
+	preview := &pb.Preview{
+		Type:               openGraphModel.Type,
+		Title:              openGraphModel.Title,
+		SiteName:           openGraphModel.SiteName,
+		Description:        openGraphModel.Description,
+		Author:             openGraphModel.Author,
+		Image:              openGraphModel.Image,
+		Url:                openGraphModel.Url,
+		Favicon:            openGraphModel.Favicon,
+		Filename:           openGraphModel.Filename,
+		Etag:               openGraphModel.Etag,
+		PreviewCard:        openGraphModel.PreviewCard,
+		Price:              openGraphModel.Price,
+		Currency:           openGraphModel.Currency,
+		Content:            openGraphModel.Content,
+		ContentPages:       int32(openGraphModel.ContentPages),
+		ContentHash:        openGraphModel.ContentHash,
+		FinalUrl:           openGraphModel.FinalUrl,
+		CanonicalUrl:       openGraphModel.CanonicalUrl,
+		FetchAttempts:      int32(openGraphModel.FetchAttempts),
+		ImageFetchAttempts: int32(openGraphModel.ImageFetchAttempts),
+		DominantColor:      openGraphModel.DominantColor,
+		Palette:            openGraphModel.Palette,
+		BlurHash:           openGraphModel.BlurHash,
+		ContentType:        openGraphModel.ContentType,
+		ContentLength:      openGraphModel.ContentLength,
+		Width:              int32(openGraphModel.Width),
+		Height:             int32(openGraphModel.Height),
+		PageCount:          int32(openGraphModel.PageCount),
+	}
+	for _, hop := range openGraphModel.RedirectChain {
+		preview.RedirectChain = append(preview.RedirectChain, &pb.RedirectHop{
+			Type:       hop.Type,
+			Url:        hop.Url,
+			StatusCode: int32(hop.StatusCode),
+			Location:   hop.Location,
+		})
+	}
+	return preview
+}
+
+func toFileInfo(info model.FileUploadInfo) *pb.FileInfo {
+	fileInfo := &pb.FileInfo{
+		Id:            int64(info.Id),
+		FileId:        info.FileId,
+		FileSize:      info.FileSize,
+		FileName:      info.FileName,
+		Ext:           info.Ext,
+		MimeType:      info.MimeType,
+		DominantColor: info.DominantColor,
+		BlurHash:      info.BlurHash,
+		CreatedTime:   info.CreatedTime,
+		UpdatedTime:   info.UpdateTime,
+	}
+	if info.Palette != "" {
+		fileInfo.Palette = strings.Split(info.Palette, ",")
+	}
+	return fileInfo
+}
@@ -0,0 +1,179 @@
+package api
+
+import (
+	"context"
+	"crawlweb/infrastructure"
+	"crawlweb/service"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	neturl "net/url"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RequestTimeout limit of one preview request, crawl of url keeps running for other waiting requests
+var RequestTimeout = 30 * time.Second
+
+var errInvalidUrl = errors.New("url must be absolute http or https url")
+
+// ErrorResponse body of error response
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+type ErrorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// NewHandler http handler of api, token is bearer token required by all requests except health check,
+// empty token allow all
+func NewHandler(token string) http.Handler {
+	auth := tokenAuth{token: token}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	mux.Handle("/v1/preview", auth.middleware(http.HandlerFunc(handlePreview)))
+	return recoverMiddleware(logMiddleware(mux))
+}
+
+// handlePreview GET /v1/preview?url=...&skip_upload=true
+func handlePreview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "only GET is allowed")
+		return
+	}
+	url := r.URL.Query().Get("url")
+	if url == "" {
+		writeError(w, http.StatusBadRequest, "missing_url", "query param url is required")
+		return
+	}
+	if err := validateUrl(url); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_url", err.Error())
+		return
+	}
+	var err error
+	options := service.CrawlOptions{}
+	if skipUpload := r.URL.Query().Get("skip_upload"); skipUpload != "" {
+		if options.SkipUpload, err = strconv.ParseBool(skipUpload); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_param", "skip_upload must be true or false")
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), RequestTimeout)
+	defer cancel()
+	openGraphModel, cached, err := service.GetPreview(ctx, url, options)
+	if err != nil {
+		status, code := errorStatus(err)
+		if status >= http.StatusInternalServerError {
+			log.Println("preview error:", url, err)
+		}
+		writeError(w, status, code, err.Error())
+		return
+	}
+	if cached {
+		w.Header().Set("X-Cache", "HIT")
+	} else {
+		w.Header().Set("X-Cache", "MISS")
+	}
+	writeJSON(w, http.StatusOK, openGraphModel)
+}
+
+// validateUrl url of preview must be absolute http or https url
+func validateUrl(url string) error {
+	u, err := neturl.Parse(url)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errInvalidUrl
+	}
+	return nil
+}
+
+// errorStatus http status and error code of crawl error
+func errorStatus(err error) (status int, code string) {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, "timeout"
+	case errors.Is(err, context.Canceled):
+		// client closed request
+		return 499, "canceled"
+	case errors.Is(err, infrastructure.ErrUrlNotAllowed):
+		return http.StatusForbidden, "url_not_allowed"
+	case errors.Is(err, service.ErrContentTypeNotAllowed):
+		return http.StatusUnsupportedMediaType, "content_type_not_allowed"
+	case errors.Is(err, service.ErrBodyTooLarge):
+		return http.StatusRequestEntityTooLarge, "body_too_large"
+	case errors.Is(err, service.ErrStatusCode):
+		return http.StatusBadGateway, "upstream_status"
+	case errors.Is(err, service.ErrRedirectLoop), errors.Is(err, service.ErrTooManyRedirects):
+		return http.StatusBadGateway, "redirect_error"
+	}
+	return http.StatusBadGateway, "fetch_failed"
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Println("write response error:", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, code string, message string) {
+	writeJSON(w, status, ErrorResponse{Error: ErrorBody{Code: code, Message: message}})
+}
+
+// statusRecorder remember status code of response for logging
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// middleware reject request without bearer token of Authorization header
+func (a tokenAuth) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.token != "" {
+			token := strings.TrimSpace(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+			if subtle.ConstantTimeCompare([]byte(token), []byte(a.token)) != 1 {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				writeError(w, http.StatusUnauthorized, "unauthenticated", "invalid or missing bearer token")
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func logMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+		log.Println(r.Method, r.URL.RequestURI(), recorder.status, time.Since(start).Round(time.Millisecond))
+	})
+}
+
+// recoverMiddleware json error response instead of closing connection when handler panic
+func recoverMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if err := recover(); err != nil {
+				log.Printf("panic: %v\n%s", err, debug.Stack())
+				writeError(w, http.StatusInternalServerError, "internal_error", "internal server error")
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
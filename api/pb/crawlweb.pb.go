@@ -0,0 +1,1517 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: crawlweb.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type GetPreviewRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Url           string                 `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	SkipUpload    bool                   `protobuf:"varint,2,opt,name=skip_upload,json=skipUpload,proto3" json:"skip_upload,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetPreviewRequest) Reset() {
+	*x = GetPreviewRequest{}
+	mi := &file_crawlweb_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPreviewRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPreviewRequest) ProtoMessage() {}
+
+func (x *GetPreviewRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_crawlweb_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPreviewRequest.ProtoReflect.Descriptor instead.
+func (*GetPreviewRequest) Descriptor() ([]byte, []int) {
+	return file_crawlweb_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *GetPreviewRequest) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *GetPreviewRequest) GetSkipUpload() bool {
+	if x != nil {
+		return x.SkipUpload
+	}
+	return false
+}
+
+type StreamPreviewsRequest struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Urls       []string               `protobuf:"bytes,1,rep,name=urls,proto3" json:"urls,omitempty"`
+	SkipUpload bool                   `protobuf:"varint,2,opt,name=skip_upload,json=skipUpload,proto3" json:"skip_upload,omitempty"`
+	// number of urls crawled at same time, default 8
+	Concurrency   int32 `protobuf:"varint,3,opt,name=concurrency,proto3" json:"concurrency,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamPreviewsRequest) Reset() {
+	*x = StreamPreviewsRequest{}
+	mi := &file_crawlweb_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamPreviewsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamPreviewsRequest) ProtoMessage() {}
+
+func (x *StreamPreviewsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_crawlweb_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamPreviewsRequest.ProtoReflect.Descriptor instead.
+func (*StreamPreviewsRequest) Descriptor() ([]byte, []int) {
+	return file_crawlweb_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *StreamPreviewsRequest) GetUrls() []string {
+	if x != nil {
+		return x.Urls
+	}
+	return nil
+}
+
+func (x *StreamPreviewsRequest) GetSkipUpload() bool {
+	if x != nil {
+		return x.SkipUpload
+	}
+	return false
+}
+
+func (x *StreamPreviewsRequest) GetConcurrency() int32 {
+	if x != nil {
+		return x.Concurrency
+	}
+	return 0
+}
+
+type PreviewResult struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// index of url in request
+	Index   int32    `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	Url     string   `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`
+	Preview *Preview `protobuf:"bytes,3,opt,name=preview,proto3" json:"preview,omitempty"`
+	// error_code and error are set when crawling url fail
+	ErrorCode     string `protobuf:"bytes,4,opt,name=error_code,json=errorCode,proto3" json:"error_code,omitempty"`
+	Error         string `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`
+	DurationMs    int64  `protobuf:"varint,6,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PreviewResult) Reset() {
+	*x = PreviewResult{}
+	mi := &file_crawlweb_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PreviewResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PreviewResult) ProtoMessage() {}
+
+func (x *PreviewResult) ProtoReflect() protoreflect.Message {
+	mi := &file_crawlweb_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PreviewResult.ProtoReflect.Descriptor instead.
+func (*PreviewResult) Descriptor() ([]byte, []int) {
+	return file_crawlweb_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *PreviewResult) GetIndex() int32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *PreviewResult) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *PreviewResult) GetPreview() *Preview {
+	if x != nil {
+		return x.Preview
+	}
+	return nil
+}
+
+func (x *PreviewResult) GetErrorCode() string {
+	if x != nil {
+		return x.ErrorCode
+	}
+	return ""
+}
+
+func (x *PreviewResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *PreviewResult) GetDurationMs() int64 {
+	if x != nil {
+		return x.DurationMs
+	}
+	return 0
+}
+
+type RedirectHop struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Url           string                 `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`
+	StatusCode    int32                  `protobuf:"varint,3,opt,name=status_code,json=statusCode,proto3" json:"status_code,omitempty"`
+	Location      string                 `protobuf:"bytes,4,opt,name=location,proto3" json:"location,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RedirectHop) Reset() {
+	*x = RedirectHop{}
+	mi := &file_crawlweb_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RedirectHop) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RedirectHop) ProtoMessage() {}
+
+func (x *RedirectHop) ProtoReflect() protoreflect.Message {
+	mi := &file_crawlweb_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RedirectHop.ProtoReflect.Descriptor instead.
+func (*RedirectHop) Descriptor() ([]byte, []int) {
+	return file_crawlweb_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *RedirectHop) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *RedirectHop) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *RedirectHop) GetStatusCode() int32 {
+	if x != nil {
+		return x.StatusCode
+	}
+	return 0
+}
+
+func (x *RedirectHop) GetLocation() string {
+	if x != nil {
+		return x.Location
+	}
+	return ""
+}
+
+type Preview struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Type               string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Title              string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	SiteName           string                 `protobuf:"bytes,3,opt,name=site_name,json=siteName,proto3" json:"site_name,omitempty"`
+	Description        string                 `protobuf:"bytes,4,opt,name=description,proto3" json:"description,omitempty"`
+	Author             string                 `protobuf:"bytes,5,opt,name=author,proto3" json:"author,omitempty"`
+	Image              string                 `protobuf:"bytes,6,opt,name=image,proto3" json:"image,omitempty"`
+	Url                string                 `protobuf:"bytes,7,opt,name=url,proto3" json:"url,omitempty"`
+	Favicon            string                 `protobuf:"bytes,8,opt,name=favicon,proto3" json:"favicon,omitempty"`
+	Filename           string                 `protobuf:"bytes,9,opt,name=filename,proto3" json:"filename,omitempty"`
+	Etag               string                 `protobuf:"bytes,10,opt,name=etag,proto3" json:"etag,omitempty"`
+	PreviewCard        string                 `protobuf:"bytes,11,opt,name=preview_card,json=previewCard,proto3" json:"preview_card,omitempty"`
+	Price              string                 `protobuf:"bytes,12,opt,name=price,proto3" json:"price,omitempty"`
+	Currency           string                 `protobuf:"bytes,13,opt,name=currency,proto3" json:"currency,omitempty"`
+	Content            string                 `protobuf:"bytes,14,opt,name=content,proto3" json:"content,omitempty"`
+	ContentPages       int32                  `protobuf:"varint,15,opt,name=content_pages,json=contentPages,proto3" json:"content_pages,omitempty"`
+	ContentHash        string                 `protobuf:"bytes,16,opt,name=content_hash,json=contentHash,proto3" json:"content_hash,omitempty"`
+	FinalUrl           string                 `protobuf:"bytes,17,opt,name=final_url,json=finalUrl,proto3" json:"final_url,omitempty"`
+	CanonicalUrl       string                 `protobuf:"bytes,18,opt,name=canonical_url,json=canonicalUrl,proto3" json:"canonical_url,omitempty"`
+	RedirectChain      []*RedirectHop         `protobuf:"bytes,19,rep,name=redirect_chain,json=redirectChain,proto3" json:"redirect_chain,omitempty"`
+	FetchAttempts      int32                  `protobuf:"varint,20,opt,name=fetch_attempts,json=fetchAttempts,proto3" json:"fetch_attempts,omitempty"`
+	ImageFetchAttempts int32                  `protobuf:"varint,21,opt,name=image_fetch_attempts,json=imageFetchAttempts,proto3" json:"image_fetch_attempts,omitempty"`
+	DominantColor      string                 `protobuf:"bytes,22,opt,name=dominant_color,json=dominantColor,proto3" json:"dominant_color,omitempty"`
+	Palette            []string               `protobuf:"bytes,23,rep,name=palette,proto3" json:"palette,omitempty"`
+	BlurHash           string                 `protobuf:"bytes,24,opt,name=blur_hash,json=blurHash,proto3" json:"blur_hash,omitempty"`
+	ContentType        string                 `protobuf:"bytes,25,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	ContentLength      int64                  `protobuf:"varint,26,opt,name=content_length,json=contentLength,proto3" json:"content_length,omitempty"`
+	Width              int32                  `protobuf:"varint,27,opt,name=width,proto3" json:"width,omitempty"`
+	Height             int32                  `protobuf:"varint,28,opt,name=height,proto3" json:"height,omitempty"`
+	PageCount          int32                  `protobuf:"varint,29,opt,name=page_count,json=pageCount,proto3" json:"page_count,omitempty"`
+	// cached is true when result is not crawled by this request
+	Cached        bool `protobuf:"varint,30,opt,name=cached,proto3" json:"cached,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Preview) Reset() {
+	*x = Preview{}
+	mi := &file_crawlweb_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Preview) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Preview) ProtoMessage() {}
+
+func (x *Preview) ProtoReflect() protoreflect.Message {
+	mi := &file_crawlweb_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Preview.ProtoReflect.Descriptor instead.
+func (*Preview) Descriptor() ([]byte, []int) {
+	return file_crawlweb_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Preview) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Preview) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Preview) GetSiteName() string {
+	if x != nil {
+		return x.SiteName
+	}
+	return ""
+}
+
+func (x *Preview) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Preview) GetAuthor() string {
+	if x != nil {
+		return x.Author
+	}
+	return ""
+}
+
+func (x *Preview) GetImage() string {
+	if x != nil {
+		return x.Image
+	}
+	return ""
+}
+
+func (x *Preview) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *Preview) GetFavicon() string {
+	if x != nil {
+		return x.Favicon
+	}
+	return ""
+}
+
+func (x *Preview) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+func (x *Preview) GetEtag() string {
+	if x != nil {
+		return x.Etag
+	}
+	return ""
+}
+
+func (x *Preview) GetPreviewCard() string {
+	if x != nil {
+		return x.PreviewCard
+	}
+	return ""
+}
+
+func (x *Preview) GetPrice() string {
+	if x != nil {
+		return x.Price
+	}
+	return ""
+}
+
+func (x *Preview) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *Preview) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *Preview) GetContentPages() int32 {
+	if x != nil {
+		return x.ContentPages
+	}
+	return 0
+}
+
+func (x *Preview) GetContentHash() string {
+	if x != nil {
+		return x.ContentHash
+	}
+	return ""
+}
+
+func (x *Preview) GetFinalUrl() string {
+	if x != nil {
+		return x.FinalUrl
+	}
+	return ""
+}
+
+func (x *Preview) GetCanonicalUrl() string {
+	if x != nil {
+		return x.CanonicalUrl
+	}
+	return ""
+}
+
+func (x *Preview) GetRedirectChain() []*RedirectHop {
+	if x != nil {
+		return x.RedirectChain
+	}
+	return nil
+}
+
+func (x *Preview) GetFetchAttempts() int32 {
+	if x != nil {
+		return x.FetchAttempts
+	}
+	return 0
+}
+
+func (x *Preview) GetImageFetchAttempts() int32 {
+	if x != nil {
+		return x.ImageFetchAttempts
+	}
+	return 0
+}
+
+func (x *Preview) GetDominantColor() string {
+	if x != nil {
+		return x.DominantColor
+	}
+	return ""
+}
+
+func (x *Preview) GetPalette() []string {
+	if x != nil {
+		return x.Palette
+	}
+	return nil
+}
+
+func (x *Preview) GetBlurHash() string {
+	if x != nil {
+		return x.BlurHash
+	}
+	return ""
+}
+
+func (x *Preview) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *Preview) GetContentLength() int64 {
+	if x != nil {
+		return x.ContentLength
+	}
+	return 0
+}
+
+func (x *Preview) GetWidth() int32 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *Preview) GetHeight() int32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *Preview) GetPageCount() int32 {
+	if x != nil {
+		return x.PageCount
+	}
+	return 0
+}
+
+func (x *Preview) GetCached() bool {
+	if x != nil {
+		return x.Cached
+	}
+	return false
+}
+
+type GetPresignedUploadUrlRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// file_name is used for extension of generated key
+	FileName      string `protobuf:"bytes,1,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetPresignedUploadUrlRequest) Reset() {
+	*x = GetPresignedUploadUrlRequest{}
+	mi := &file_crawlweb_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPresignedUploadUrlRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPresignedUploadUrlRequest) ProtoMessage() {}
+
+func (x *GetPresignedUploadUrlRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_crawlweb_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPresignedUploadUrlRequest.ProtoReflect.Descriptor instead.
+func (*GetPresignedUploadUrlRequest) Descriptor() ([]byte, []int) {
+	return file_crawlweb_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetPresignedUploadUrlRequest) GetFileName() string {
+	if x != nil {
+		return x.FileName
+	}
+	return ""
+}
+
+type GetPresignedUploadUrlResponse struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Key              string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Url              string                 `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`
+	ExpiresInSeconds int64                  `protobuf:"varint,3,opt,name=expires_in_seconds,json=expiresInSeconds,proto3" json:"expires_in_seconds,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *GetPresignedUploadUrlResponse) Reset() {
+	*x = GetPresignedUploadUrlResponse{}
+	mi := &file_crawlweb_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPresignedUploadUrlResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPresignedUploadUrlResponse) ProtoMessage() {}
+
+func (x *GetPresignedUploadUrlResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_crawlweb_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPresignedUploadUrlResponse.ProtoReflect.Descriptor instead.
+func (*GetPresignedUploadUrlResponse) Descriptor() ([]byte, []int) {
+	return file_crawlweb_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetPresignedUploadUrlResponse) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *GetPresignedUploadUrlResponse) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *GetPresignedUploadUrlResponse) GetExpiresInSeconds() int64 {
+	if x != nil {
+		return x.ExpiresInSeconds
+	}
+	return 0
+}
+
+type CompleteUploadRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CompleteUploadRequest) Reset() {
+	*x = CompleteUploadRequest{}
+	mi := &file_crawlweb_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CompleteUploadRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CompleteUploadRequest) ProtoMessage() {}
+
+func (x *CompleteUploadRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_crawlweb_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CompleteUploadRequest.ProtoReflect.Descriptor instead.
+func (*CompleteUploadRequest) Descriptor() ([]byte, []int) {
+	return file_crawlweb_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *CompleteUploadRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+type GetPresignedMultipartUploadUrlsRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	FileName string                 `protobuf:"bytes,1,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`
+	FileSize int64                  `protobuf:"varint,2,opt,name=file_size,json=fileSize,proto3" json:"file_size,omitempty"`
+	// part_size default 5MB, minimum 5MB
+	PartSize      int64 `protobuf:"varint,3,opt,name=part_size,json=partSize,proto3" json:"part_size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetPresignedMultipartUploadUrlsRequest) Reset() {
+	*x = GetPresignedMultipartUploadUrlsRequest{}
+	mi := &file_crawlweb_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPresignedMultipartUploadUrlsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPresignedMultipartUploadUrlsRequest) ProtoMessage() {}
+
+func (x *GetPresignedMultipartUploadUrlsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_crawlweb_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPresignedMultipartUploadUrlsRequest.ProtoReflect.Descriptor instead.
+func (*GetPresignedMultipartUploadUrlsRequest) Descriptor() ([]byte, []int) {
+	return file_crawlweb_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GetPresignedMultipartUploadUrlsRequest) GetFileName() string {
+	if x != nil {
+		return x.FileName
+	}
+	return ""
+}
+
+func (x *GetPresignedMultipartUploadUrlsRequest) GetFileSize() int64 {
+	if x != nil {
+		return x.FileSize
+	}
+	return 0
+}
+
+func (x *GetPresignedMultipartUploadUrlsRequest) GetPartSize() int64 {
+	if x != nil {
+		return x.PartSize
+	}
+	return 0
+}
+
+type PresignedPart struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PartNumber    int32                  `protobuf:"varint,1,opt,name=part_number,json=partNumber,proto3" json:"part_number,omitempty"`
+	Url           string                 `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PresignedPart) Reset() {
+	*x = PresignedPart{}
+	mi := &file_crawlweb_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PresignedPart) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PresignedPart) ProtoMessage() {}
+
+func (x *PresignedPart) ProtoReflect() protoreflect.Message {
+	mi := &file_crawlweb_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PresignedPart.ProtoReflect.Descriptor instead.
+func (*PresignedPart) Descriptor() ([]byte, []int) {
+	return file_crawlweb_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *PresignedPart) GetPartNumber() int32 {
+	if x != nil {
+		return x.PartNumber
+	}
+	return 0
+}
+
+func (x *PresignedPart) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+type GetPresignedMultipartUploadUrlsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	UploadId      string                 `protobuf:"bytes,2,opt,name=upload_id,json=uploadId,proto3" json:"upload_id,omitempty"`
+	Parts         []*PresignedPart       `protobuf:"bytes,3,rep,name=parts,proto3" json:"parts,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetPresignedMultipartUploadUrlsResponse) Reset() {
+	*x = GetPresignedMultipartUploadUrlsResponse{}
+	mi := &file_crawlweb_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPresignedMultipartUploadUrlsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPresignedMultipartUploadUrlsResponse) ProtoMessage() {}
+
+func (x *GetPresignedMultipartUploadUrlsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_crawlweb_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPresignedMultipartUploadUrlsResponse.ProtoReflect.Descriptor instead.
+func (*GetPresignedMultipartUploadUrlsResponse) Descriptor() ([]byte, []int) {
+	return file_crawlweb_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *GetPresignedMultipartUploadUrlsResponse) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *GetPresignedMultipartUploadUrlsResponse) GetUploadId() string {
+	if x != nil {
+		return x.UploadId
+	}
+	return ""
+}
+
+func (x *GetPresignedMultipartUploadUrlsResponse) GetParts() []*PresignedPart {
+	if x != nil {
+		return x.Parts
+	}
+	return nil
+}
+
+type CompletedPart struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PartNumber    int32                  `protobuf:"varint,1,opt,name=part_number,json=partNumber,proto3" json:"part_number,omitempty"`
+	Etag          string                 `protobuf:"bytes,2,opt,name=etag,proto3" json:"etag,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CompletedPart) Reset() {
+	*x = CompletedPart{}
+	mi := &file_crawlweb_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CompletedPart) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CompletedPart) ProtoMessage() {}
+
+func (x *CompletedPart) ProtoReflect() protoreflect.Message {
+	mi := &file_crawlweb_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CompletedPart.ProtoReflect.Descriptor instead.
+func (*CompletedPart) Descriptor() ([]byte, []int) {
+	return file_crawlweb_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *CompletedPart) GetPartNumber() int32 {
+	if x != nil {
+		return x.PartNumber
+	}
+	return 0
+}
+
+func (x *CompletedPart) GetEtag() string {
+	if x != nil {
+		return x.Etag
+	}
+	return ""
+}
+
+type CompleteMultipartUploadRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	UploadId      string                 `protobuf:"bytes,2,opt,name=upload_id,json=uploadId,proto3" json:"upload_id,omitempty"`
+	Parts         []*CompletedPart       `protobuf:"bytes,3,rep,name=parts,proto3" json:"parts,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CompleteMultipartUploadRequest) Reset() {
+	*x = CompleteMultipartUploadRequest{}
+	mi := &file_crawlweb_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CompleteMultipartUploadRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CompleteMultipartUploadRequest) ProtoMessage() {}
+
+func (x *CompleteMultipartUploadRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_crawlweb_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CompleteMultipartUploadRequest.ProtoReflect.Descriptor instead.
+func (*CompleteMultipartUploadRequest) Descriptor() ([]byte, []int) {
+	return file_crawlweb_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *CompleteMultipartUploadRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *CompleteMultipartUploadRequest) GetUploadId() string {
+	if x != nil {
+		return x.UploadId
+	}
+	return ""
+}
+
+func (x *CompleteMultipartUploadRequest) GetParts() []*CompletedPart {
+	if x != nil {
+		return x.Parts
+	}
+	return nil
+}
+
+type CompleteMultipartUploadResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Etag          string                 `protobuf:"bytes,2,opt,name=etag,proto3" json:"etag,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CompleteMultipartUploadResponse) Reset() {
+	*x = CompleteMultipartUploadResponse{}
+	mi := &file_crawlweb_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CompleteMultipartUploadResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CompleteMultipartUploadResponse) ProtoMessage() {}
+
+func (x *CompleteMultipartUploadResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_crawlweb_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CompleteMultipartUploadResponse.ProtoReflect.Descriptor instead.
+func (*CompleteMultipartUploadResponse) Descriptor() ([]byte, []int) {
+	return file_crawlweb_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *CompleteMultipartUploadResponse) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *CompleteMultipartUploadResponse) GetEtag() string {
+	if x != nil {
+		return x.Etag
+	}
+	return ""
+}
+
+type AbortMultipartUploadRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	UploadId      string                 `protobuf:"bytes,2,opt,name=upload_id,json=uploadId,proto3" json:"upload_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AbortMultipartUploadRequest) Reset() {
+	*x = AbortMultipartUploadRequest{}
+	mi := &file_crawlweb_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AbortMultipartUploadRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AbortMultipartUploadRequest) ProtoMessage() {}
+
+func (x *AbortMultipartUploadRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_crawlweb_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AbortMultipartUploadRequest.ProtoReflect.Descriptor instead.
+func (*AbortMultipartUploadRequest) Descriptor() ([]byte, []int) {
+	return file_crawlweb_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *AbortMultipartUploadRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *AbortMultipartUploadRequest) GetUploadId() string {
+	if x != nil {
+		return x.UploadId
+	}
+	return ""
+}
+
+type AbortMultipartUploadResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AbortMultipartUploadResponse) Reset() {
+	*x = AbortMultipartUploadResponse{}
+	mi := &file_crawlweb_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AbortMultipartUploadResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AbortMultipartUploadResponse) ProtoMessage() {}
+
+func (x *AbortMultipartUploadResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_crawlweb_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AbortMultipartUploadResponse.ProtoReflect.Descriptor instead.
+func (*AbortMultipartUploadResponse) Descriptor() ([]byte, []int) {
+	return file_crawlweb_proto_rawDescGZIP(), []int{15}
+}
+
+type GetFileInfoRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FileName      string                 `protobuf:"bytes,1,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetFileInfoRequest) Reset() {
+	*x = GetFileInfoRequest{}
+	mi := &file_crawlweb_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetFileInfoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetFileInfoRequest) ProtoMessage() {}
+
+func (x *GetFileInfoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_crawlweb_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetFileInfoRequest.ProtoReflect.Descriptor instead.
+func (*GetFileInfoRequest) Descriptor() ([]byte, []int) {
+	return file_crawlweb_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *GetFileInfoRequest) GetFileName() string {
+	if x != nil {
+		return x.FileName
+	}
+	return ""
+}
+
+type FileInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	FileId        int64                  `protobuf:"varint,2,opt,name=file_id,json=fileId,proto3" json:"file_id,omitempty"`
+	FileSize      int64                  `protobuf:"varint,3,opt,name=file_size,json=fileSize,proto3" json:"file_size,omitempty"`
+	FileName      string                 `protobuf:"bytes,4,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`
+	Ext           string                 `protobuf:"bytes,5,opt,name=ext,proto3" json:"ext,omitempty"`
+	MimeType      string                 `protobuf:"bytes,6,opt,name=mime_type,json=mimeType,proto3" json:"mime_type,omitempty"`
+	DominantColor string                 `protobuf:"bytes,7,opt,name=dominant_color,json=dominantColor,proto3" json:"dominant_color,omitempty"`
+	Palette       []string               `protobuf:"bytes,8,rep,name=palette,proto3" json:"palette,omitempty"`
+	BlurHash      string                 `protobuf:"bytes,9,opt,name=blur_hash,json=blurHash,proto3" json:"blur_hash,omitempty"`
+	CreatedTime   int64                  `protobuf:"varint,10,opt,name=created_time,json=createdTime,proto3" json:"created_time,omitempty"`
+	UpdatedTime   int64                  `protobuf:"varint,11,opt,name=updated_time,json=updatedTime,proto3" json:"updated_time,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FileInfo) Reset() {
+	*x = FileInfo{}
+	mi := &file_crawlweb_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FileInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FileInfo) ProtoMessage() {}
+
+func (x *FileInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_crawlweb_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FileInfo.ProtoReflect.Descriptor instead.
+func (*FileInfo) Descriptor() ([]byte, []int) {
+	return file_crawlweb_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *FileInfo) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *FileInfo) GetFileId() int64 {
+	if x != nil {
+		return x.FileId
+	}
+	return 0
+}
+
+func (x *FileInfo) GetFileSize() int64 {
+	if x != nil {
+		return x.FileSize
+	}
+	return 0
+}
+
+func (x *FileInfo) GetFileName() string {
+	if x != nil {
+		return x.FileName
+	}
+	return ""
+}
+
+func (x *FileInfo) GetExt() string {
+	if x != nil {
+		return x.Ext
+	}
+	return ""
+}
+
+func (x *FileInfo) GetMimeType() string {
+	if x != nil {
+		return x.MimeType
+	}
+	return ""
+}
+
+func (x *FileInfo) GetDominantColor() string {
+	if x != nil {
+		return x.DominantColor
+	}
+	return ""
+}
+
+func (x *FileInfo) GetPalette() []string {
+	if x != nil {
+		return x.Palette
+	}
+	return nil
+}
+
+func (x *FileInfo) GetBlurHash() string {
+	if x != nil {
+		return x.BlurHash
+	}
+	return ""
+}
+
+func (x *FileInfo) GetCreatedTime() int64 {
+	if x != nil {
+		return x.CreatedTime
+	}
+	return 0
+}
+
+func (x *FileInfo) GetUpdatedTime() int64 {
+	if x != nil {
+		return x.UpdatedTime
+	}
+	return 0
+}
+
+var File_crawlweb_proto protoreflect.FileDescriptor
+
+const file_crawlweb_proto_rawDesc = "" +
+	"\n" +
+	"\x0ecrawlweb.proto\x12\vcrawlweb.v1\"F\n" +
+	"\x11GetPreviewRequest\x12\x10\n" +
+	"\x03url\x18\x01 \x01(\tR\x03url\x12\x1f\n" +
+	"\vskip_upload\x18\x02 \x01(\bR\n" +
+	"skipUpload\"n\n" +
+	"\x15StreamPreviewsRequest\x12\x12\n" +
+	"\x04urls\x18\x01 \x03(\tR\x04urls\x12\x1f\n" +
+	"\vskip_upload\x18\x02 \x01(\bR\n" +
+	"skipUpload\x12 \n" +
+	"\vconcurrency\x18\x03 \x01(\x05R\vconcurrency\"\xbd\x01\n" +
+	"\rPreviewResult\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\x05R\x05index\x12\x10\n" +
+	"\x03url\x18\x02 \x01(\tR\x03url\x12.\n" +
+	"\apreview\x18\x03 \x01(\v2\x14.crawlweb.v1.PreviewR\apreview\x12\x1d\n" +
+	"\n" +
+	"error_code\x18\x04 \x01(\tR\terrorCode\x12\x14\n" +
+	"\x05error\x18\x05 \x01(\tR\x05error\x12\x1f\n" +
+	"\vduration_ms\x18\x06 \x01(\x03R\n" +
+	"durationMs\"p\n" +
+	"\vRedirectHop\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x10\n" +
+	"\x03url\x18\x02 \x01(\tR\x03url\x12\x1f\n" +
+	"\vstatus_code\x18\x03 \x01(\x05R\n" +
+	"statusCode\x12\x1a\n" +
+	"\blocation\x18\x04 \x01(\tR\blocation\"\x9c\a\n" +
+	"\aPreview\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12\x1b\n" +
+	"\tsite_name\x18\x03 \x01(\tR\bsiteName\x12 \n" +
+	"\vdescription\x18\x04 \x01(\tR\vdescription\x12\x16\n" +
+	"\x06author\x18\x05 \x01(\tR\x06author\x12\x14\n" +
+	"\x05image\x18\x06 \x01(\tR\x05image\x12\x10\n" +
+	"\x03url\x18\a \x01(\tR\x03url\x12\x18\n" +
+	"\afavicon\x18\b \x01(\tR\afavicon\x12\x1a\n" +
+	"\bfilename\x18\t \x01(\tR\bfilename\x12\x12\n" +
+	"\x04etag\x18\n" +
+	" \x01(\tR\x04etag\x12!\n" +
+	"\fpreview_card\x18\v \x01(\tR\vpreviewCard\x12\x14\n" +
+	"\x05price\x18\f \x01(\tR\x05price\x12\x1a\n" +
+	"\bcurrency\x18\r \x01(\tR\bcurrency\x12\x18\n" +
+	"\acontent\x18\x0e \x01(\tR\acontent\x12#\n" +
+	"\rcontent_pages\x18\x0f \x01(\x05R\fcontentPages\x12!\n" +
+	"\fcontent_hash\x18\x10 \x01(\tR\vcontentHash\x12\x1b\n" +
+	"\tfinal_url\x18\x11 \x01(\tR\bfinalUrl\x12#\n" +
+	"\rcanonical_url\x18\x12 \x01(\tR\fcanonicalUrl\x12?\n" +
+	"\x0eredirect_chain\x18\x13 \x03(\v2\x18.crawlweb.v1.RedirectHopR\rredirectChain\x12%\n" +
+	"\x0efetch_attempts\x18\x14 \x01(\x05R\rfetchAttempts\x120\n" +
+	"\x14image_fetch_attempts\x18\x15 \x01(\x05R\x12imageFetchAttempts\x12%\n" +
+	"\x0edominant_color\x18\x16 \x01(\tR\rdominantColor\x12\x18\n" +
+	"\apalette\x18\x17 \x03(\tR\apalette\x12\x1b\n" +
+	"\tblur_hash\x18\x18 \x01(\tR\bblurHash\x12!\n" +
+	"\fcontent_type\x18\x19 \x01(\tR\vcontentType\x12%\n" +
+	"\x0econtent_length\x18\x1a \x01(\x03R\rcontentLength\x12\x14\n" +
+	"\x05width\x18\x1b \x01(\x05R\x05width\x12\x16\n" +
+	"\x06height\x18\x1c \x01(\x05R\x06height\x12\x1d\n" +
+	"\n" +
+	"page_count\x18\x1d \x01(\x05R\tpageCount\x12\x16\n" +
+	"\x06cached\x18\x1e \x01(\bR\x06cached\";\n" +
+	"\x1cGetPresignedUploadUrlRequest\x12\x1b\n" +
+	"\tfile_name\x18\x01 \x01(\tR\bfileName\"q\n" +
+	"\x1dGetPresignedUploadUrlResponse\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x10\n" +
+	"\x03url\x18\x02 \x01(\tR\x03url\x12,\n" +
+	"\x12expires_in_seconds\x18\x03 \x01(\x03R\x10expiresInSeconds\")\n" +
+	"\x15CompleteUploadRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\"\x7f\n" +
+	"&GetPresignedMultipartUploadUrlsRequest\x12\x1b\n" +
+	"\tfile_name\x18\x01 \x01(\tR\bfileName\x12\x1b\n" +
+	"\tfile_size\x18\x02 \x01(\x03R\bfileSize\x12\x1b\n" +
+	"\tpart_size\x18\x03 \x01(\x03R\bpartSize\"B\n" +
+	"\rPresignedPart\x12\x1f\n" +
+	"\vpart_number\x18\x01 \x01(\x05R\n" +
+	"partNumber\x12\x10\n" +
+	"\x03url\x18\x02 \x01(\tR\x03url\"\x8a\x01\n" +
+	"'GetPresignedMultipartUploadUrlsResponse\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x1b\n" +
+	"\tupload_id\x18\x02 \x01(\tR\buploadId\x120\n" +
+	"\x05parts\x18\x03 \x03(\v2\x1a.crawlweb.v1.PresignedPartR\x05parts\"D\n" +
+	"\rCompletedPart\x12\x1f\n" +
+	"\vpart_number\x18\x01 \x01(\x05R\n" +
+	"partNumber\x12\x12\n" +
+	"\x04etag\x18\x02 \x01(\tR\x04etag\"\x81\x01\n" +
+	"\x1eCompleteMultipartUploadRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x1b\n" +
+	"\tupload_id\x18\x02 \x01(\tR\buploadId\x120\n" +
+	"\x05parts\x18\x03 \x03(\v2\x1a.crawlweb.v1.CompletedPartR\x05parts\"G\n" +
+	"\x1fCompleteMultipartUploadResponse\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x12\n" +
+	"\x04etag\x18\x02 \x01(\tR\x04etag\"L\n" +
+	"\x1bAbortMultipartUploadRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x1b\n" +
+	"\tupload_id\x18\x02 \x01(\tR\buploadId\"\x1e\n" +
+	"\x1cAbortMultipartUploadResponse\"1\n" +
+	"\x12GetFileInfoRequest\x12\x1b\n" +
+	"\tfile_name\x18\x01 \x01(\tR\bfileName\"\xc0\x02\n" +
+	"\bFileInfo\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x17\n" +
+	"\afile_id\x18\x02 \x01(\x03R\x06fileId\x12\x1b\n" +
+	"\tfile_size\x18\x03 \x01(\x03R\bfileSize\x12\x1b\n" +
+	"\tfile_name\x18\x04 \x01(\tR\bfileName\x12\x10\n" +
+	"\x03ext\x18\x05 \x01(\tR\x03ext\x12\x1b\n" +
+	"\tmime_type\x18\x06 \x01(\tR\bmimeType\x12%\n" +
+	"\x0edominant_color\x18\a \x01(\tR\rdominantColor\x12\x18\n" +
+	"\apalette\x18\b \x03(\tR\apalette\x12\x1b\n" +
+	"\tblur_hash\x18\t \x01(\tR\bblurHash\x12!\n" +
+	"\fcreated_time\x18\n" +
+	" \x01(\x03R\vcreatedTime\x12!\n" +
+	"\fupdated_time\x18\v \x01(\x03R\vupdatedTime2\xa6\x01\n" +
+	"\fCrawlService\x12B\n" +
+	"\n" +
+	"GetPreview\x12\x1e.crawlweb.v1.GetPreviewRequest\x1a\x14.crawlweb.v1.Preview\x12R\n" +
+	"\x0eStreamPreviews\x12\".crawlweb.v1.StreamPreviewsRequest\x1a\x1a.crawlweb.v1.PreviewResult0\x012\x83\x05\n" +
+	"\vFileService\x12n\n" +
+	"\x15GetPresignedUploadUrl\x12).crawlweb.v1.GetPresignedUploadUrlRequest\x1a*.crawlweb.v1.GetPresignedUploadUrlResponse\x12K\n" +
+	"\x0eCompleteUpload\x12\".crawlweb.v1.CompleteUploadRequest\x1a\x15.crawlweb.v1.FileInfo\x12\x8c\x01\n" +
+	"\x1fGetPresignedMultipartUploadUrls\x123.crawlweb.v1.GetPresignedMultipartUploadUrlsRequest\x1a4.crawlweb.v1.GetPresignedMultipartUploadUrlsResponse\x12t\n" +
+	"\x17CompleteMultipartUpload\x12+.crawlweb.v1.CompleteMultipartUploadRequest\x1a,.crawlweb.v1.CompleteMultipartUploadResponse\x12k\n" +
+	"\x14AbortMultipartUpload\x12(.crawlweb.v1.AbortMultipartUploadRequest\x1a).crawlweb.v1.AbortMultipartUploadResponse\x12E\n" +
+	"\vGetFileInfo\x12\x1f.crawlweb.v1.GetFileInfoRequest\x1a\x15.crawlweb.v1.FileInfoB\x14Z\x12crawlweb/api/pb;pbb\x06proto3"
+
+var (
+	file_crawlweb_proto_rawDescOnce sync.Once
+	file_crawlweb_proto_rawDescData []byte
+)
+
+func file_crawlweb_proto_rawDescGZIP() []byte {
+	file_crawlweb_proto_rawDescOnce.Do(func() {
+		file_crawlweb_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_crawlweb_proto_rawDesc), len(file_crawlweb_proto_rawDesc)))
+	})
+	return file_crawlweb_proto_rawDescData
+}
+
+var file_crawlweb_proto_msgTypes = make([]protoimpl.MessageInfo, 18)
+var file_crawlweb_proto_goTypes = []any{
+	(*GetPreviewRequest)(nil),                       // 0: crawlweb.v1.GetPreviewRequest
+	(*StreamPreviewsRequest)(nil),                   // 1: crawlweb.v1.StreamPreviewsRequest
+	(*PreviewResult)(nil),                           // 2: crawlweb.v1.PreviewResult
+	(*RedirectHop)(nil),                             // 3: crawlweb.v1.RedirectHop
+	(*Preview)(nil),                                 // 4: crawlweb.v1.Preview
+	(*GetPresignedUploadUrlRequest)(nil),            // 5: crawlweb.v1.GetPresignedUploadUrlRequest
+	(*GetPresignedUploadUrlResponse)(nil),           // 6: crawlweb.v1.GetPresignedUploadUrlResponse
+	(*CompleteUploadRequest)(nil),                   // 7: crawlweb.v1.CompleteUploadRequest
+	(*GetPresignedMultipartUploadUrlsRequest)(nil),  // 8: crawlweb.v1.GetPresignedMultipartUploadUrlsRequest
+	(*PresignedPart)(nil),                           // 9: crawlweb.v1.PresignedPart
+	(*GetPresignedMultipartUploadUrlsResponse)(nil), // 10: crawlweb.v1.GetPresignedMultipartUploadUrlsResponse
+	(*CompletedPart)(nil),                           // 11: crawlweb.v1.CompletedPart
+	(*CompleteMultipartUploadRequest)(nil),          // 12: crawlweb.v1.CompleteMultipartUploadRequest
+	(*CompleteMultipartUploadResponse)(nil),         // 13: crawlweb.v1.CompleteMultipartUploadResponse
+	(*AbortMultipartUploadRequest)(nil),             // 14: crawlweb.v1.AbortMultipartUploadRequest
+	(*AbortMultipartUploadResponse)(nil),            // 15: crawlweb.v1.AbortMultipartUploadResponse
+	(*GetFileInfoRequest)(nil),                      // 16: crawlweb.v1.GetFileInfoRequest
+	(*FileInfo)(nil),                                // 17: crawlweb.v1.FileInfo
+}
+var file_crawlweb_proto_depIdxs = []int32{
+	4,  // 0: crawlweb.v1.PreviewResult.preview:type_name -> crawlweb.v1.Preview
+	3,  // 1: crawlweb.v1.Preview.redirect_chain:type_name -> crawlweb.v1.RedirectHop
+	9,  // 2: crawlweb.v1.GetPresignedMultipartUploadUrlsResponse.parts:type_name -> crawlweb.v1.PresignedPart
+	11, // 3: crawlweb.v1.CompleteMultipartUploadRequest.parts:type_name -> crawlweb.v1.CompletedPart
+	0,  // 4: crawlweb.v1.CrawlService.GetPreview:input_type -> crawlweb.v1.GetPreviewRequest
+	1,  // 5: crawlweb.v1.CrawlService.StreamPreviews:input_type -> crawlweb.v1.StreamPreviewsRequest
+	5,  // 6: crawlweb.v1.FileService.GetPresignedUploadUrl:input_type -> crawlweb.v1.GetPresignedUploadUrlRequest
+	7,  // 7: crawlweb.v1.FileService.CompleteUpload:input_type -> crawlweb.v1.CompleteUploadRequest
+	8,  // 8: crawlweb.v1.FileService.GetPresignedMultipartUploadUrls:input_type -> crawlweb.v1.GetPresignedMultipartUploadUrlsRequest
+	12, // 9: crawlweb.v1.FileService.CompleteMultipartUpload:input_type -> crawlweb.v1.CompleteMultipartUploadRequest
+	14, // 10: crawlweb.v1.FileService.AbortMultipartUpload:input_type -> crawlweb.v1.AbortMultipartUploadRequest
+	16, // 11: crawlweb.v1.FileService.GetFileInfo:input_type -> crawlweb.v1.GetFileInfoRequest
+	4,  // 12: crawlweb.v1.CrawlService.GetPreview:output_type -> crawlweb.v1.Preview
+	2,  // 13: crawlweb.v1.CrawlService.StreamPreviews:output_type -> crawlweb.v1.PreviewResult
+	6,  // 14: crawlweb.v1.FileService.GetPresignedUploadUrl:output_type -> crawlweb.v1.GetPresignedUploadUrlResponse
+	17, // 15: crawlweb.v1.FileService.CompleteUpload:output_type -> crawlweb.v1.FileInfo
+	10, // 16: crawlweb.v1.FileService.GetPresignedMultipartUploadUrls:output_type -> crawlweb.v1.GetPresignedMultipartUploadUrlsResponse
+	13, // 17: crawlweb.v1.FileService.CompleteMultipartUpload:output_type -> crawlweb.v1.CompleteMultipartUploadResponse
+	15, // 18: crawlweb.v1.FileService.AbortMultipartUpload:output_type -> crawlweb.v1.AbortMultipartUploadResponse
+	17, // 19: crawlweb.v1.FileService.GetFileInfo:output_type -> crawlweb.v1.FileInfo
+	12, // [12:20] is the sub-list for method output_type
+	4,  // [4:12] is the sub-list for method input_type
+	4,  // [4:4] is the sub-list for extension type_name
+	4,  // [4:4] is the sub-list for extension extendee
+	0,  // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_crawlweb_proto_init() }
+func file_crawlweb_proto_init() {
+	if File_crawlweb_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_crawlweb_proto_rawDesc), len(file_crawlweb_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   18,
+			NumExtensions: 0,
+			NumServices:   2,
+		},
+		GoTypes:           file_crawlweb_proto_goTypes,
+		DependencyIndexes: file_crawlweb_proto_depIdxs,
+		MessageInfos:      file_crawlweb_proto_msgTypes,
+	}.Build()
+	File_crawlweb_proto = out.File
+	file_crawlweb_proto_goTypes = nil
+	file_crawlweb_proto_depIdxs = nil
+}
@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"context"
+	"crawlweb/model"
+	"crawlweb/service"
+	"fmt"
+	"io"
+	"os"
+)
+
+func runBatch(ctx context.Context, args []string) int {
+	flags := newFlagSet("batch", "[file of urls, - for stdin]")
+	output := flags.String("o", "output.jsonl", "output path, - for stdout")
+	format := addFormatFlag(flags)
+	workers := flags.Int("workers", service.DefaultBatchConfig.Workers, "number of urls crawled at same time")
+	timeout := flags.Duration("timeout", service.DefaultBatchConfig.Timeout, "timeout of each url")
+	crawlOptions := addCrawlOptionFlags(flags)
+	addWebhookFlag(flags)
+	if ok, code := parseFlags(flags, args); !ok {
+		return code
+	}
+	options, err := crawlOptions()
+	if err != nil {
+		return exitCode(err)
+	}
+	recordFormat, err := outputFormat(*format, *output, service.FORMAT_JSONL)
+	if err != nil {
+		return exitCode(err)
+	}
+
+	var input io.Reader = os.Stdin
+	if path := flags.Arg(0); path != "" && path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return exitCode(err)
+		}
+		defer file.Close()
+		input = file
+	}
+	writer, err := openOutput(*output, false)
+	if err != nil {
+		return exitCode(err)
+	}
+	recordWriter, err := service.NewRecordWriter(writer, recordFormat)
+	if err != nil {
+		writer.Close()
+		return exitCode(err)
+	}
+
+	config := service.DefaultBatchConfig
+	config.Workers = *workers
+	config.Timeout = *timeout
+	// record writer is closed by RunBatch, output is closed before webhook is notified
+	summary, err := service.RunBatch(ctx, input, recordWriter, config, options)
+	if errClose := writer.Close(); errClose != nil && err == nil {
+		err = errClose
+	}
+	fmt.Fprintln(os.Stderr, summary)
+	event := model.JobEventData{
+		Job:        flags.Arg(0),
+		Output:     *output,
+		Total:      summary.Total,
+		Succeeded:  summary.Succeeded,
+		Failed:     summary.Failed,
+		DurationMs: summary.Duration.Milliseconds(),
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	notifyWebhook(ctx, service.WEBHOOK_BATCH_COMPLETED, event)
+	if err != nil {
+		return exitCode(err)
+	}
+	switch {
+	case summary.Failed > 0 && summary.Succeeded == 0:
+		return EXIT_CRAWL_FAILED
+	case summary.Failed > 0:
+		return EXIT_PARTIAL
+	}
+	return EXIT_OK
+}
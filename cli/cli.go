@@ -0,0 +1,252 @@
+package cli
+
+import (
+	"context"
+	"crawlweb/infrastructure"
+	"crawlweb/service"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+)
+
+// exit codes of commands
+const (
+	EXIT_OK = 0
+	// EXIT_ERROR unexpected error (storage, database, io...)
+	EXIT_ERROR = 1
+	// EXIT_USAGE invalid command, flag or argument
+	EXIT_USAGE = 2
+	// EXIT_CRAWL_FAILED url could not be fetched or parsed
+	EXIT_CRAWL_FAILED = 3
+	// EXIT_PARTIAL some urls of batch or site crawl failed
+	EXIT_PARTIAL = 4
+	// EXIT_INTERRUPTED stopped by SIGINT or SIGTERM, progress of job is saved
+	EXIT_INTERRUPTED = 130
+)
+
+const usage = `Usage: crawlweb <command> [flags] [args]
+
+Commands:
+  crawl     crawl url (or site with -site) and write open graph info
+  batch     crawl urls of file or stdin, write one record per url
+  watch     manage watch list and re-crawl it on schedule
+  upload    upload local file or url to storage
+  download  download file from storage
+  drive     manage files and folders of google drive
+  db        query database
+  serve     run http and grpc server
+  webhook   manage webhooks and replay failed deliveries
+  worker    consume crawl and upload jobs of redis stream, or enqueue jobs
+
+Global flags (before command):
+  -config file   yaml or json fetcher config (default $CRAWLWEB_CONFIG)
+
+Run "crawlweb <command> -h" for flags of command.
+Without command, url is asked interactively.
+`
+
+type command struct {
+	name string
+	run  func(ctx context.Context, args []string) int
+}
+
+var commands = []command{
+	{"crawl", runCrawl},
+	{"batch", runBatch},
+	{"watch", runWatch},
+	{"upload", runUpload},
+	{"download", runDownload},
+	{"drive", runDrive},
+	{"db", runDb},
+	{"serve", runServe},
+	{"webhook", runWebhook},
+	{"worker", runWorker},
+}
+
+// usageError error of command arguments, exit code is EXIT_USAGE
+type usageError struct {
+	message string
+}
+
+func (e usageError) Error() string {
+	return e.message
+}
+
+func newUsageError(format string, args ...interface{}) error {
+	return usageError{message: fmt.Sprintf(format, args...)}
+}
+
+// Run run command of args (without program name), return exit code
+func Run(args []string) int {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	defer infrastructure.CloseWarc()
+
+	configPath, args, err := configFlag(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n\n%s", err, usage)
+		return EXIT_USAGE
+	}
+	if len(args) > 0 && (args[0] == "help" || args[0] == "-h" || args[0] == "-help" || args[0] == "--help") {
+		fmt.Fprint(os.Stdout, usage)
+		return EXIT_OK
+	}
+	// config is loaded before first fetch, usage error of config exit with EXIT_USAGE
+	if err := infrastructure.LoadFetcherConfig(configPath); err != nil {
+		return exitCode(usageError{message: err.Error()})
+	}
+
+	// no command or flags only: crawl command (interactive when there is no url)
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") && args[0] != "-h" && args[0] != "-help" && args[0] != "--help" {
+		return runCrawl(ctx, args)
+	}
+	name := args[0]
+	for _, command := range commands {
+		if command.name == name {
+			return command.run(ctx, args[1:])
+		}
+	}
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", name, usage)
+	return EXIT_USAGE
+}
+
+// enableWarc turn on WARC capture of fetcher config to dir, files are closed by Run before exit
+func enableWarc(dir string) {
+	config := infrastructure.GetFetcherConfig()
+	config.Warc.Enabled = true
+	config.Warc.Dir = dir
+	infrastructure.SetFetcherConfig(config)
+}
+
+// configFlag take -config flag at start of args, default is $CRAWLWEB_CONFIG
+func configFlag(args []string) (path string, rest []string, err error) {
+	path = os.Getenv(infrastructure.ENV_FETCHER_CONFIG)
+	if len(args) == 0 {
+		return path, args, nil
+	}
+	switch arg := args[0]; {
+	case arg == "-config" || arg == "--config":
+		if len(args) < 2 {
+			return "", nil, newUsageError("flag needs an argument: -config")
+		}
+		return args[1], args[2:], nil
+	case strings.HasPrefix(arg, "-config=") || strings.HasPrefix(arg, "--config="):
+		return arg[strings.Index(arg, "=")+1:], args[1:], nil
+	}
+	return path, args, nil
+}
+
+// newFlagSet flag set which returns error instead of exit
+func newFlagSet(name string, argsUsage string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: crawlweb %s [flags] %s\n\nFlags:\n", name, argsUsage)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// parseFlags parse args, flags can be after positional args (ex: watch add url -interval 1h).
+// code is exit code when parsing fail or help is printed
+func parseFlags(flags *flag.FlagSet, args []string) (ok bool, code int) {
+	positional := []string{}
+	for {
+		err := flags.Parse(args)
+		if err == flag.ErrHelp {
+			return false, EXIT_OK
+		}
+		if err != nil {
+			return false, EXIT_USAGE
+		}
+		args = flags.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+	flags.Parse(append([]string{"--"}, positional...))
+	return true, EXIT_OK
+}
+
+// exitCode print error and return its exit code
+func exitCode(err error) int {
+	if err == nil {
+		return EXIT_OK
+	}
+	fmt.Fprintln(os.Stderr, "error:", err)
+	var errUsage usageError
+	switch {
+	case errors.As(err, &errUsage):
+		return EXIT_USAGE
+	case errors.Is(err, context.Canceled):
+		return EXIT_INTERRUPTED
+	}
+	return EXIT_ERROR
+}
+
+// openOutput file of path, stdout for "-"
+func openOutput(path string, appendFile bool) (io.WriteCloser, error) {
+	if path == "-" {
+		return nopWriteCloser{os.Stdout}, nil
+	}
+	fileFlag := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if appendFile {
+		fileFlag = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	return os.OpenFile(path, fileFlag, 0644)
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// addFormatFlag -format flag, format is detected from extension of output path when it is empty
+func addFormatFlag(flags *flag.FlagSet) *string {
+	return flags.String("format", "", "output format: "+strings.Join(service.OutputFormats, ", ")+" (default by extension of output path)")
+}
+
+// outputFormat format of -format flag, or by extension of output, or defaultFormat
+func outputFormat(format string, output string, defaultFormat string) (string, error) {
+	if format == "" {
+		format = service.FormatFromPath(output)
+	}
+	if format == "" {
+		return defaultFormat, nil
+	}
+	if format == "md" {
+		format = service.FORMAT_MARKDOWN
+	}
+	for _, outputFormat := range service.OutputFormats {
+		if format == outputFormat {
+			return format, nil
+		}
+	}
+	return "", newUsageError("invalid format %q", format)
+}
+
+// addCrawlOptionFlags flags of service.CrawlOptions and -warc-out shared by crawl, batch and watch
+func addCrawlOptionFlags(flags *flag.FlagSet) func() (service.CrawlOptions, error) {
+	skipUpload := flags.Bool("skip-upload", false, "do not upload image and preview card (same as -storage none)")
+	storage := flags.String("storage", service.STORAGE_S3, "storage of image and preview card: s3, drive or none")
+	paginate := flags.Bool("paginate", false, "follow next/previous pages of article and stitch its content")
+	warcOut := flags.String("warc-out", "", "record every request and response to WARC files of this directory")
+	return func() (service.CrawlOptions, error) {
+		switch *storage {
+		case service.STORAGE_S3, service.STORAGE_DRIVE, service.STORAGE_NONE:
+		default:
+			return service.CrawlOptions{}, newUsageError("invalid storage %q", *storage)
+		}
+		if *warcOut != "" {
+			enableWarc(*warcOut)
+		}
+		return service.CrawlOptions{SkipUpload: *skipUpload, Storage: *storage, FollowPagination: *paginate}, nil
+	}
+}
@@ -0,0 +1,296 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"crawlweb/model"
+	"crawlweb/service"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+func runCrawl(ctx context.Context, args []string) int {
+	flags := newFlagSet("crawl", "[url]")
+	url := flags.String("url", "", "url to crawl, local file (file://...) is parsed offline")
+	output := flags.String("o", "", "output path, - for stdout (default output.json, output.jsonl for site crawl)")
+	format := addFormatFlag(flags)
+	stdin := flags.Bool("stdin", false, "read raw html from stdin instead of fetching url")
+	warc := flags.String("warc", "", "replay response of url from WARC file")
+	baseUrl := flags.String("base-url", "", "original url of offline html, used for resolving relative links")
+	crawlOptions := addCrawlOptionFlags(flags)
+	addWebhookFlag(flags)
+	// site crawl
+	site := flags.Bool("site", false, "crawl site from seeds of url (comma separated), write JSON Lines")
+	depth := flags.Int("depth", 2, "max link depth from seeds")
+	maxPages := flags.Int("max-pages", 100, "max pages to crawl, 0 is unlimited")
+	maxPagesPerHost := flags.Int("max-pages-per-host", 0, "max pages of each host, 0 is unlimited")
+	scope := flags.String("scope", service.SCOPE_HOST, "follow links of: host, domain or any")
+	pathPrefix := flags.String("path-prefix", "", "only follow links with this path prefix")
+	include := flags.String("include", "", "only follow links matching this regex")
+	exclude := flags.String("exclude", "", "do not follow links matching this regex")
+	frontier := flags.String("frontier", "", "name of redis frontier shared with other workers of site crawl")
+	frontierReset := flags.Bool("frontier-reset", false, "delete queued and seen urls of frontier before crawl (start again)")
+	job := flags.String("job", "", "name of site crawl job, its progress is saved for -resume")
+	resume := flags.String("resume", "", "resume site crawl job with this name, output is appended")
+	if ok, code := parseFlags(flags, args); !ok {
+		return code
+	}
+	options, err := crawlOptions()
+	if err != nil {
+		return exitCode(err)
+	}
+	if *url == "" {
+		*url = strings.TrimSpace(flags.Arg(0))
+	}
+
+	if *site || *resume != "" {
+		if *url == "" && *resume == "" {
+			return exitCode(newUsageError("url is required for site crawl"))
+		}
+		if *resume != "" {
+			// resumed job appends to output of job and uses its crawl options
+			checkpoint, err := service.LoadCheckpoint(*resume)
+			if err != nil {
+				return exitCode(checkpointError(err))
+			}
+			if *output == "" {
+				*output = checkpoint.Output
+			}
+		}
+		if *output == "" {
+			*output = "output.jsonl"
+		}
+		recordFormat, err := outputFormat(*format, *output, service.FORMAT_JSONL)
+		if err != nil {
+			return exitCode(err)
+		}
+		if (*resume != "" || *job != "") && recordFormat != service.FORMAT_JSONL {
+			return exitCode(newUsageError("output of resumed crawl job is appended, format must be jsonl"))
+		}
+		config := service.SiteCrawlConfig{
+			Seeds:           strings.Split(*url, ","),
+			MaxDepth:        *depth,
+			MaxPages:        *maxPages,
+			Scope:           *scope,
+			PathPrefix:      *pathPrefix,
+			IncludePattern:  *include,
+			ExcludePattern:  *exclude,
+			MaxPagesPerHost: *maxPagesPerHost,
+		}
+		if *frontierReset {
+			if *frontier == "" {
+				return exitCode(newUsageError("-frontier-reset requires -frontier"))
+			}
+			if err := resetFrontier(ctx, *frontier); err != nil {
+				return exitCode(err)
+			}
+		}
+		return crawlSite(ctx, config, options, *output, recordFormat, *frontier, *job, *resume)
+	}
+
+	if *url == "" && !*stdin {
+		var err error
+		*url, err = promptUrl()
+		if err != nil {
+			return exitCode(err)
+		}
+	}
+	if *output == "" {
+		*output = "output.json"
+	}
+	recordFormat, err := outputFormat(*format, *output, service.FORMAT_JSON)
+	if err != nil {
+		return exitCode(err)
+	}
+
+	var openGraphModel model.OpenGraphModel
+	switch {
+	case *stdin:
+		openGraphModel, err = service.CrawlOffline(ctx, *baseUrl, options, func() (*http.Response, error) {
+			return service.ReaderResponse(ctx, os.Stdin, *baseUrl)
+		})
+	case *warc != "":
+		openGraphModel, err = service.CrawlOffline(ctx, *url, options, func() (*http.Response, error) {
+			return service.WarcResponse(ctx, *warc, *url)
+		})
+	case strings.HasPrefix(*url, "file://"):
+		openGraphModel, err = service.CrawlOffline(ctx, *baseUrl, options, func() (*http.Response, error) {
+			return service.FileResponse(ctx, *url, *baseUrl)
+		})
+	default:
+		openGraphModel, err = service.Crawl(ctx, *url, options)
+	}
+	crawledUrl := *url
+	if crawledUrl == "" {
+		crawledUrl = *baseUrl
+	}
+	// offline parsing does not use network nor database, no webhook event
+	offline := *stdin || *warc != "" || strings.HasPrefix(*url, "file://")
+	notify := func(eventType string, data model.CrawlEventData) {
+		if !offline {
+			notifyWebhook(ctx, eventType, data)
+		}
+	}
+	if err != nil {
+		notify(service.WEBHOOK_CRAWL_FAILED, model.CrawlEventData{Url: crawledUrl, Error: err.Error()})
+		return crawlExitCode(err)
+	}
+
+	// webhook is notified after output is written and closed, receiver can read it
+	err = writeOutput(*output, recordFormat, openGraphModel)
+	if err != nil {
+		notify(service.WEBHOOK_CRAWL_FAILED, model.CrawlEventData{Url: crawledUrl, Error: err.Error()})
+		return exitCode(err)
+	}
+	notify(service.WEBHOOK_CRAWL_COMPLETED, model.CrawlEventData{Url: crawledUrl, Result: &openGraphModel})
+	return EXIT_OK
+}
+
+// writeOutput write record to output path and close it
+func writeOutput(output string, format string, record interface{}) (err error) {
+	writer, err := openOutput(output, false)
+	if err != nil {
+		return
+	}
+	err = service.WriteRecord(writer, format, record)
+	if errClose := writer.Close(); err == nil {
+		err = errClose
+	}
+	return
+}
+
+func crawlSite(ctx context.Context, config service.SiteCrawlConfig, options service.CrawlOptions, output string, format string, frontier string, job string, resume string) int {
+	writer, err := openOutput(output, resume != "")
+	if err != nil {
+		return exitCode(err)
+	}
+	recordWriter, err := service.NewRecordWriter(writer, format)
+	if err != nil {
+		writer.Close()
+		return exitCode(err)
+	}
+	start := time.Now()
+	total, failed := 0, 0
+	emit := func(record model.CrawlRecord) {
+		total++
+		if record.Error != "" {
+			failed++
+		}
+		if err := recordWriter.Write(record); err != nil {
+			log.Println("write record error:", err)
+		}
+	}
+	switch {
+	case resume != "":
+		err = service.ResumeCrawlJob(ctx, resume, emit)
+	case job != "":
+		// resume can run from other working directory
+		jobOutput := output
+		if absOutput, errAbs := filepath.Abs(output); errAbs == nil && output != "-" {
+			jobOutput = absOutput
+		}
+		err = service.CrawlSiteJob(ctx, job, config, options, jobOutput, emit)
+	case frontier != "":
+		frontierConfig := service.DefaultFrontierConfig
+		frontierConfig.Name = frontier
+		var queue *service.Frontier
+		if queue, err = service.NewFrontier(frontierConfig); err == nil {
+			err = service.CrawlFrontier(ctx, queue, config, options, emit)
+		}
+	default:
+		err = service.CrawlSite(ctx, config, options, emit)
+	}
+	// output is closed before webhook is notified
+	errClose := recordWriter.Close()
+	if errCloseFile := writer.Close(); errClose == nil {
+		errClose = errCloseFile
+	}
+	if errClose != nil {
+		log.Println("write output error:", errClose)
+		if err == nil {
+			err = errClose
+		}
+	}
+	if errors.Is(err, service.ErrCheckpointNotFound) || errors.Is(err, service.ErrCrawlJobDone) {
+		return exitCode(checkpointError(err))
+	}
+	event := model.JobEventData{
+		Job:        firstNonEmpty(resume, job, frontier),
+		Output:     output,
+		Total:      total,
+		Succeeded:  total - failed,
+		Failed:     failed,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	notifyWebhook(ctx, service.WEBHOOK_SITE_CRAWL_COMPLETED, event)
+	if err != nil {
+		return exitCode(err)
+	}
+	if failed > 0 {
+		return EXIT_PARTIAL
+	}
+	return EXIT_OK
+}
+
+// resetFrontier clear frontier of name, must not be run while other workers are crawling it
+func resetFrontier(ctx context.Context, name string) error {
+	frontierConfig := service.DefaultFrontierConfig
+	frontierConfig.Name = name
+	queue, err := service.NewFrontier(frontierConfig)
+	if err != nil {
+		return err
+	}
+	return queue.Clear(ctx)
+}
+
+// checkpointError usage error for job which is not found or done
+func checkpointError(err error) error {
+	if errors.Is(err, service.ErrCheckpointNotFound) || errors.Is(err, service.ErrCrawlJobDone) {
+		return usageError{message: err.Error()}
+	}
+	return err
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// promptUrl ask url when it is not given by argument
+func promptUrl() (string, error) {
+	fmt.Fprintln(os.Stderr, "---------------- Start crawl website--------------------")
+	reader := bufio.NewReader(os.Stdin)
+	fmt.Fprint(os.Stderr, "Vui lòng nhập URL: ")
+	input, err := reader.ReadString('\n')
+	if err != nil && (err != io.EOF || input == "") {
+		return "", newUsageError("url is required")
+	}
+	url := strings.TrimSpace(input)
+	if url == "" {
+		return "", newUsageError("url is required")
+	}
+	return url, nil
+}
+
+// crawlExitCode EXIT_CRAWL_FAILED for error of fetching or parsing url
+func crawlExitCode(err error) int {
+	if errors.Is(err, context.Canceled) {
+		return exitCode(err)
+	}
+	fmt.Fprintln(os.Stderr, "crawl error:", err)
+	return EXIT_CRAWL_FAILED
+}
@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// offline crawl must not need database nor redis, even when webhooks are enabled
+func TestRunCrawlStdinWithoutDatabase(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "page.html")
+	html := `<html><head><title>Offline page</title><meta property="og:description" content="parsed from stdin"></head><body><p>text</p></body></html>`
+	if err := ioutil.WriteFile(input, []byte(html), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stdin, err := os.Open(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdin.Close()
+	oldStdin := os.Stdin
+	os.Stdin = stdin
+	defer func() { os.Stdin = oldStdin }()
+	t.Setenv(ENV_WEBHOOKS, "true")
+
+	output := filepath.Join(dir, "out.json")
+	code := Run([]string{"crawl", "-stdin", "-base-url", "https://example.com/a", "-storage", "none", "-o", output})
+	if code != EXIT_OK {
+		t.Fatalf("exit code = %d, want %d", code, EXIT_OK)
+	}
+	data, err := ioutil.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result struct {
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		FinalUrl    string `json:"finalUrl"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatalf("output is not json: %v: %s", err, data)
+	}
+	if result.Title != "Offline page" || result.Description != "parsed from stdin" || result.FinalUrl != "https://example.com/a" {
+		t.Errorf("output = %+v", result)
+	}
+}
@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"context"
+	"crawlweb/infrastructure"
+	"crawlweb/service"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+const dbUsage = `<ping | files>`
+
+func runDb(ctx context.Context, args []string) int {
+	flags := newFlagSet("db", dbUsage)
+	limit := flags.Int("limit", 20, "number of rows")
+	if ok, code := parseFlags(flags, args); !ok {
+		return code
+	}
+	switch flags.Arg(0) {
+	case "ping":
+		db, err := infrastructure.GetDB()
+		if err != nil {
+			return exitCode(err)
+		}
+		if err := db.PingContext(ctx); err != nil {
+			return exitCode(err)
+		}
+		fmt.Fprintln(os.Stderr, "database is ok")
+		return EXIT_OK
+	case "files":
+		infos, err := service.ListFileUploadInfos(ctx, *limit)
+		if err != nil {
+			return exitCode(err)
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		for _, info := range infos {
+			if err := encoder.Encode(info); err != nil {
+				return exitCode(err)
+			}
+		}
+		return EXIT_OK
+	}
+	flags.Usage()
+	return EXIT_USAGE
+}
@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"context"
+	"crawlweb/api"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+const (
+	// ENV_GRPC_TOKEN default bearer token of grpc server
+	ENV_GRPC_TOKEN = "CRAWLWEB_GRPC_TOKEN"
+	// ENV_HTTP_TOKEN default bearer token of http server
+	ENV_HTTP_TOKEN = "CRAWLWEB_HTTP_TOKEN"
+)
+
+func runServe(ctx context.Context, args []string) int {
+	flags := newFlagSet("serve", "")
+	addr := flags.String("addr", "127.0.0.1:8080", "listen address")
+	token := flags.String("token", os.Getenv(ENV_HTTP_TOKEN), "bearer token required by http server (default $"+ENV_HTTP_TOKEN+")")
+	grpcAddr := flags.String("grpc-addr", "127.0.0.1:9090", "listen address of grpc server, empty is disabled")
+	grpcToken := flags.String("grpc-token", os.Getenv(ENV_GRPC_TOKEN), "bearer token required by grpc server (default $"+ENV_GRPC_TOKEN+")")
+	timeout := flags.Duration("timeout", api.RequestTimeout, "timeout of preview request")
+	addWebhookFlag(flags)
+	if ok, code := parseFlags(flags, args); !ok {
+		return code
+	}
+
+	api.RequestTimeout = *timeout
+	if *token == "" && !isLoopbackAddr(*addr) {
+		log.Println("warning: http server listens on", *addr, "without -token, anyone can crawl any url through it")
+	}
+	server := &http.Server{
+		Addr:              *addr,
+		Handler:           api.NewHandler(*token),
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		// response is written after crawl of url is finished
+		WriteTimeout: *timeout + 10*time.Second,
+		IdleTimeout:  2 * time.Minute,
+	}
+	if *grpcAddr == "" {
+		return exitCode(serveUntilDone(ctx, server))
+	}
+
+	listener, err := net.Listen("tcp", *grpcAddr)
+	if err != nil {
+		log.Println("listen grpc error:", err)
+		return EXIT_ERROR
+	}
+	if *grpcToken == "" && !isLoopbackAddr(*grpcAddr) {
+		log.Println("warning: grpc server listens on", *grpcAddr, "without -grpc-token, anyone can crawl and get presigned upload urls")
+	}
+	grpcServer, healthServer := api.NewGrpcServer(*grpcToken)
+	errGrpc := make(chan error, 1)
+	go func() {
+		log.Println("grpc listening on", *grpcAddr)
+		errGrpc <- grpcServer.Serve(listener)
+	}()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		// http server is stopped too when grpc server fail
+		if err := <-errGrpc; err != nil {
+			log.Println("grpc server error:", err)
+		}
+		cancel()
+	}()
+
+	err = serveUntilDone(ctx, server)
+	healthServer.Shutdown()
+	stopGrpcServer(grpcServer, 30*time.Second)
+	return exitCode(err)
+}
+
+// isLoopbackAddr address only reachable from this host
+func isLoopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// stopGrpcServer wait running rpcs in timeout then close all connections
+func stopGrpcServer(server *grpc.Server, timeout time.Duration) {
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(timeout):
+		server.Stop()
+	}
+}
+
+// serveUntilDone run server until ctx is done then shutdown it gracefully
+func serveUntilDone(ctx context.Context, server *http.Server) error {
+	errServe := make(chan error, 1)
+	go func() {
+		log.Println("listening on", server.Addr)
+		errServe <- server.ListenAndServe()
+	}()
+	select {
+	case err := <-errServe:
+		return err
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	return server.Shutdown(shutdownCtx)
+}
@@ -0,0 +1,144 @@
+package cli
+
+import (
+	"context"
+	"crawlweb/infrastructure"
+	"crawlweb/model"
+	"crawlweb/service"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// uploadResult output of upload command
+type uploadResult struct {
+	Storage  string `json:"storage"`
+	Filename string `json:"filename"`
+	Etag     string `json:"etag,omitempty"`
+}
+
+func runUpload(ctx context.Context, args []string) int {
+	flags := newFlagSet("upload", "<local file or url>")
+	storage := flags.String("storage", service.STORAGE_S3, "storage: s3 or drive")
+	addWebhookFlag(flags)
+	if ok, code := parseFlags(flags, args); !ok {
+		return code
+	}
+	source := flags.Arg(0)
+	if source == "" {
+		return exitCode(newUsageError("file or url is required"))
+	}
+	isUrl := strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+
+	result := uploadResult{Storage: *storage}
+	var err error
+	switch *storage {
+	case service.STORAGE_S3:
+		if isUrl {
+			result.Filename, result.Etag, _, _, err = service.UploadFileToBucket(source, "")
+		} else {
+			result.Filename, result.Etag, err = service.UploadLocalFile(source)
+		}
+	case service.STORAGE_DRIVE:
+		if isUrl {
+			result.Filename = service.CreateFileAndSave(source)
+			if result.Filename == "" {
+				err = fmt.Errorf("upload %s to drive fail", source)
+			}
+		} else {
+			result.Filename, err = service.UploadLocalFileToDrive(source, mime.TypeByExtension(filepath.Ext(source)))
+		}
+	default:
+		return exitCode(newUsageError("invalid storage %q", *storage))
+	}
+	event := model.UploadEventData{Source: source, Storage: result.Storage, Filename: result.Filename, Etag: result.Etag}
+	if err != nil {
+		event.Error = err.Error()
+		notifyWebhook(ctx, service.WEBHOOK_UPLOAD_FAILED, event)
+		return exitCode(err)
+	}
+	notifyWebhook(ctx, service.WEBHOOK_UPLOAD_COMPLETED, event)
+	return exitCode(json.NewEncoder(os.Stdout).Encode(result))
+}
+
+func runDownload(ctx context.Context, args []string) int {
+	flags := newFlagSet("download", "<s3 filename or drive file id>")
+	storage := flags.String("storage", service.STORAGE_S3, "storage: s3 or drive")
+	output := flags.String("o", "", "output path (default base name of file)")
+	if ok, code := parseFlags(flags, args); !ok {
+		return code
+	}
+	name := flags.Arg(0)
+	if name == "" {
+		return exitCode(newUsageError("file name is required"))
+	}
+	if *output == "" {
+		*output = filepath.Base(name)
+	}
+	switch *storage {
+	case service.STORAGE_S3:
+		return exitCode(service.DownloadFileFromBucket(name, *output))
+	case service.STORAGE_DRIVE:
+		return exitCode(service.DownloadFileFromDrive(name, *output))
+	}
+	return exitCode(newUsageError("invalid storage %q", *storage))
+}
+
+const driveUsage = `<upload path | download file-id [output] | mkdir name | rm file-id>`
+
+func runDrive(ctx context.Context, args []string) int {
+	flags := newFlagSet("drive", driveUsage)
+	parent := flags.String("parent", "", "parent folder id (default root folder)")
+	if ok, code := parseFlags(flags, args); !ok {
+		return code
+	}
+	action, arg := flags.Arg(0), flags.Arg(1)
+	if action != "" && arg == "" {
+		return exitCode(newUsageError("argument of %s is required", action))
+	}
+	if *parent == "" {
+		*parent = infrastructure.GetRootFolderDrive()
+	}
+	driveService, err := infrastructure.GetDriveService()
+	if err != nil {
+		return exitCode(err)
+	}
+	encoder := json.NewEncoder(os.Stdout)
+
+	switch action {
+	case "upload":
+		file, err := os.Open(arg)
+		if err != nil {
+			return exitCode(err)
+		}
+		defer file.Close()
+		mimeType := mime.TypeByExtension(filepath.Ext(arg))
+		if mimeType == "" {
+			mimeType = infrastructure.MIME_File
+		}
+		driveFile, err := service.CreateFile(driveService, filepath.Base(arg), mimeType, file, *parent)
+		if err != nil {
+			return exitCode(err)
+		}
+		return exitCode(encoder.Encode(driveFile))
+	case "download":
+		output := flags.Arg(2)
+		if output == "" {
+			output = arg
+		}
+		return exitCode(service.DownloadFileFromDrive(arg, output))
+	case "mkdir":
+		folder, err := service.CreateFolder(driveService, arg, *parent)
+		if err != nil {
+			return exitCode(err)
+		}
+		return exitCode(encoder.Encode(folder))
+	case "rm":
+		return exitCode(service.DeleteFile(driveService, arg))
+	}
+	flags.Usage()
+	return EXIT_USAGE
+}
@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"context"
+	"crawlweb/model"
+	"crawlweb/service"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+const watchUsage = `<add url | remove url | list | history watch-id | run>`
+
+func runWatch(ctx context.Context, args []string) int {
+	flags := newFlagSet("watch", watchUsage)
+	interval := flags.Duration("interval", time.Hour, "re-crawl interval of added url")
+	crawlOptions := addCrawlOptionFlags(flags)
+	if ok, code := parseFlags(flags, args); !ok {
+		return code
+	}
+	options, err := crawlOptions()
+	if err != nil {
+		return exitCode(err)
+	}
+	action, arg := flags.Arg(0), flags.Arg(1)
+	encoder := json.NewEncoder(os.Stdout)
+
+	switch action {
+	case "add":
+		if arg == "" {
+			return exitCode(newUsageError("url is required"))
+		}
+		watch, err := service.AddWatch(ctx, arg, *interval)
+		if err != nil {
+			return exitCode(err)
+		}
+		fmt.Fprintln(os.Stderr, "watching:", watch.Url, "every", time.Duration(watch.IntervalSeconds)*time.Second)
+	case "remove":
+		if arg == "" {
+			return exitCode(newUsageError("url is required"))
+		}
+		return exitCode(service.RemoveWatch(ctx, arg))
+	case "list":
+		watches, err := service.ListWatches(ctx)
+		if err != nil {
+			return exitCode(err)
+		}
+		for _, watch := range watches {
+			encoder.Encode(watch)
+		}
+	case "history":
+		watchId, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			return exitCode(newUsageError("invalid watch id %q", arg))
+		}
+		versions, err := service.GetWatchHistory(ctx, watchId)
+		if err != nil {
+			return exitCode(err)
+		}
+		for _, version := range versions {
+			encoder.Encode(version)
+		}
+	case "run":
+		// change events are written to stdout as JSON Lines
+		err := service.RunWatcher(ctx, options, func(event model.WatchChangeEvent) {
+			if err := encoder.Encode(event); err != nil {
+				log.Println("write event error:", err)
+			}
+		})
+		return exitCode(err)
+	default:
+		flags.Usage()
+		return EXIT_USAGE
+	}
+	return EXIT_OK
+}
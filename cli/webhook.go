@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"context"
+	"crawlweb/service"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const webhookUsage = `<add url | remove id | list | deliveries | replay [delivery-id...]>`
+
+func runWebhook(ctx context.Context, args []string) int {
+	flags := newFlagSet("webhook", webhookUsage)
+	secret := flags.String("secret", "", "secret of HMAC-SHA256 signature of added webhook (default random)")
+	events := flags.String("events", "", "comma separated events of added webhook (default all): "+strings.Join(service.WebhookEventTypes, ", "))
+	status := flags.String("status", "", "status of listed deliveries: pending, succeeded or failed (default all)")
+	limit := flags.Int("limit", 100, "max listed deliveries")
+	if ok, code := parseFlags(flags, args); !ok {
+		return code
+	}
+	action, arg := flags.Arg(0), flags.Arg(1)
+	encoder := json.NewEncoder(os.Stdout)
+
+	switch action {
+	case "add":
+		if arg == "" {
+			return exitCode(newUsageError("url is required"))
+		}
+		var eventTypes []string
+		if *events != "" {
+			eventTypes = strings.Split(*events, ",")
+		}
+		endpoint, err := service.AddWebhook(ctx, arg, *secret, eventTypes)
+		if errors.Is(err, service.ErrUnknownWebhookEvent) {
+			return exitCode(usageError{message: err.Error()})
+		}
+		if err != nil {
+			return exitCode(err)
+		}
+		// secret is printed once for configuring receiver
+		return exitCode(encoder.Encode(endpoint))
+	case "remove":
+		id, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			return exitCode(newUsageError("invalid webhook id %q", arg))
+		}
+		return exitCode(service.RemoveWebhook(ctx, id))
+	case "list":
+		endpoints, err := service.ListWebhooks(ctx)
+		if err != nil {
+			return exitCode(err)
+		}
+		for _, endpoint := range endpoints {
+			endpoint.Secret = maskSecret(endpoint.Secret)
+			encoder.Encode(endpoint)
+		}
+	case "deliveries":
+		deliveries, err := service.ListWebhookDeliveries(ctx, *status, *limit)
+		if err != nil {
+			return exitCode(err)
+		}
+		for _, delivery := range deliveries {
+			encoder.Encode(delivery)
+		}
+	case "replay":
+		// without ids, all failed deliveries and stuck pending deliveries are replayed
+		var ids []int64
+		for _, arg := range flags.Args()[1:] {
+			id, err := strconv.ParseInt(arg, 10, 64)
+			if err != nil {
+				return exitCode(newUsageError("invalid delivery id %q", arg))
+			}
+			ids = append(ids, id)
+		}
+		replayed, err := service.ReplayWebhookDeliveries(ctx, ids)
+		fmt.Fprintln(os.Stderr, "replayed deliveries:", replayed)
+		return exitCode(err)
+	default:
+		flags.Usage()
+		return EXIT_USAGE
+	}
+	return EXIT_OK
+}
+
+// ENV_WEBHOOKS default of -webhooks flag (true or 1)
+const ENV_WEBHOOKS = "CRAWLWEB_WEBHOOKS"
+
+// addWebhookFlag -webhooks flag of command which emits events, webhooks are read from database only when it is set
+func addWebhookFlag(flags *flag.FlagSet) {
+	enabled, _ := strconv.ParseBool(os.Getenv(ENV_WEBHOOKS))
+	flags.BoolVar(&service.WebhooksEnabled, "webhooks", enabled, "emit events to webhooks of database (default $"+ENV_WEBHOOKS+")")
+}
+
+// notifyWebhook emit event to webhooks, error of delivery (or database) does not change exit code of command
+func notifyWebhook(ctx context.Context, eventType string, data interface{}) {
+	if ctx.Err() != nil {
+		return
+	}
+	if err := service.EmitWebhookEvent(ctx, eventType, data); err != nil {
+		log.Println("webhook", eventType, "error:", err)
+	}
+}
+
+func maskSecret(secret string) string {
+	if len(secret) <= 10 {
+		return "***"
+	}
+	return secret[:6] + "***" + secret[len(secret)-4:]
+}
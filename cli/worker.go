@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"context"
+	"crawlweb/model"
+	"crawlweb/service"
+	"fmt"
+	"os"
+)
+
+const workerUsage = `[run | enqueue url...]`
+
+func runWorker(ctx context.Context, args []string) int {
+	flags := newFlagSet("worker", workerUsage)
+	config := service.DefaultStreamWorkerConfig
+	addWebhookFlag(flags)
+	flags.StringVar(&config.Stream, "stream", config.Stream, "redis stream of jobs")
+	flags.StringVar(&config.Group, "group", config.Group, "consumer group, workers of same group share jobs")
+	flags.StringVar(&config.Consumer, "consumer", "", "consumer name, unique in group (default hostname-pid)")
+	flags.StringVar(&config.ResultStream, "result-stream", config.ResultStream, "redis stream of job results")
+	flags.StringVar(&config.DeadLetterStream, "dead-letter-stream", config.DeadLetterStream, "redis stream of jobs which failed max deliveries times")
+	flags.IntVar(&config.Workers, "workers", config.Workers, "number of jobs processed at same time")
+	flags.Int64Var(&config.MaxDeliveries, "max-deliveries", config.MaxDeliveries, "job is moved to dead letter stream after it is delivered this many times")
+	flags.DurationVar(&config.JobTimeout, "job-timeout", config.JobTimeout, "timeout of each job")
+	flags.DurationVar(&config.ClaimIdle, "claim-idle", config.ClaimIdle, "failed job or job of stopped consumer is claimed after this idle time")
+	// enqueue
+	jobType := flags.String("type", service.STREAM_JOB_CRAWL, "type of enqueued jobs: crawl or upload")
+	jobId := flags.String("job-id", "", "job id of enqueued jobs, copied to their results")
+	storage := flags.String("storage", "", "storage of enqueued jobs: s3, drive or none (default s3)")
+	skipUpload := flags.Bool("skip-upload", false, "enqueued crawl jobs do not upload image and preview card")
+	if ok, code := parseFlags(flags, args); !ok {
+		return code
+	}
+
+	switch flags.Arg(0) {
+	case "", "run":
+		return exitCode(service.RunStreamWorker(ctx, config))
+	case "enqueue":
+		urls := flags.Args()[1:]
+		if len(urls) == 0 {
+			return exitCode(newUsageError("url is required"))
+		}
+		for _, url := range urls {
+			job := model.StreamJob{JobId: *jobId, Type: *jobType, Url: url, Storage: *storage, SkipUpload: *skipUpload}
+			messageId, err := service.EnqueueStreamJob(ctx, config.Stream, job)
+			if err != nil {
+				return exitCode(err)
+			}
+			fmt.Fprintln(os.Stdout, messageId)
+		}
+	default:
+		flags.Usage()
+		return EXIT_USAGE
+	}
+	return EXIT_OK
+}
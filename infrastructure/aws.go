@@ -1,7 +1,8 @@
 package infrastructure
 
 import (
-	"log"
+	"fmt"
+	"sync"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
@@ -14,25 +15,36 @@ const (
 
 var (
 	awsSession *session.Session
+	awsMutex   sync.Mutex
 )
 
-func loadAwsService() {
-	var err error
-	awsSession, err = session.NewSession(&aws.Config{
+func loadAwsService() (*session.Session, error) {
+	newSession, err := session.NewSession(&aws.Config{
 		Region: aws.String(region),
 		// Credentials: credentials.NewStaticCredentials("AKID", "SECRET_KEY", "TOKEN"),
 	},
 	)
 
 	if err != nil {
-		log.Fatal("Unable to connect sdk: ", err)
+		return nil, fmt.Errorf("unable to connect sdk: %w", err)
 	}
+	return newSession, nil
 }
 
 func GetBucketName() string {
 	return bucket
 }
 
-func GetAwsSession() *session.Session {
-	return awsSession
+// GetAwsSession export aws session, it is loaded at first call and loaded again at next call when it fails
+func GetAwsSession() (*session.Session, error) {
+	awsMutex.Lock()
+	defer awsMutex.Unlock()
+	if awsSession == nil {
+		newSession, err := loadAwsService()
+		if err != nil {
+			return nil, err
+		}
+		awsSession = newSession
+	}
+	return awsSession, nil
 }
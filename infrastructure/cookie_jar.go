@@ -0,0 +1,334 @@
+package infrastructure
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	COOKIE_STORE_MEMORY = ""
+	COOKIE_STORE_REDIS  = "redis"
+	COOKIE_STORE_FILE   = "file"
+
+	COOKIE_JAR_PREFIX = "cookie_jar:"
+	// COOKIE_JAR_REFRESH redis jar is read again after this time, cookies set by other processes are used
+	COOKIE_JAR_REFRESH = 30 * time.Second
+)
+
+// SessionProfile login session of a domain (and its subdomains)
+type SessionProfile struct {
+	Domain string `yaml:"domain"`
+	// CookieStore where cookies are persisted: memory, redis or file
+	CookieStore string `yaml:"cookieStore"`
+	// CookieFile json file of cookie jar when CookieStore is file
+	CookieFile string `yaml:"cookieFile"`
+	// CookiesTxt Netscape cookies.txt file, imported when jar is loaded
+	CookiesTxt string `yaml:"cookiesTxt"`
+	// Headers static auth headers, ex: Authorization
+	Headers map[string]string `yaml:"headers"`
+	Login   *FormLogin        `yaml:"login"`
+}
+
+// FormLogin submit login form before crawling when jar has no SuccessCookie
+type FormLogin struct {
+	Url string `yaml:"url"`
+	// FormSelector css selector of login form, hidden inputs (csrf token...) are submitted too
+	FormSelector  string            `yaml:"formSelector"`
+	Fields        map[string]string `yaml:"fields"`
+	SuccessCookie string            `yaml:"successCookie"`
+}
+
+type jarCookie struct {
+	Name     string
+	Value    string
+	Domain   string
+	HostOnly bool
+	Path     string
+	Secure   bool
+	HttpOnly bool
+	Expires  time.Time
+}
+
+// sessionJar cookie jar of session profiles, hosts without profile do not keep cookies
+type sessionJar struct {
+	mutex sync.Mutex
+	// loaded time of jar of profile domain, jar which is failed to load is not in map
+	loaded  map[string]time.Time
+	cookies map[string][]*jarCookie
+}
+
+var cookieJar = &sessionJar{
+	loaded:  map[string]time.Time{},
+	cookies: map[string][]*jarCookie{},
+}
+
+// GetSessionProfile profile of host, nil if host do not have profile
+func GetSessionProfile(host string) *SessionProfile {
+	host = strings.ToLower(host)
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	for i := range fetcherConfig.SessionProfiles {
+		profile := &fetcherConfig.SessionProfiles[i]
+		domain := strings.ToLower(strings.TrimPrefix(profile.Domain, "."))
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return profile
+		}
+	}
+	return nil
+}
+
+// GetSessionCookie value of cookie name in jar of profile
+func GetSessionCookie(profile *SessionProfile, name string) string {
+	cookieJar.mutex.Lock()
+	defer cookieJar.mutex.Unlock()
+	cookieJar.load(profile)
+	for _, c := range cookieJar.cookies[profile.Domain] {
+		if c.Name == name && (c.Expires.IsZero() || c.Expires.After(time.Now())) {
+			return c.Value
+		}
+	}
+	return ""
+}
+
+func (jar *sessionJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	profile := GetSessionProfile(u.Host)
+	if profile == nil {
+		return
+	}
+	jar.mutex.Lock()
+	defer jar.mutex.Unlock()
+	jar.load(profile)
+
+	now := time.Now()
+	for _, c := range cookies {
+		cookie := &jarCookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Domain:   strings.ToLower(strings.TrimPrefix(c.Domain, ".")),
+			Path:     c.Path,
+			Secure:   c.Secure,
+			HttpOnly: c.HttpOnly,
+			Expires:  c.Expires,
+		}
+		if cookie.Domain == "" {
+			cookie.Domain = strings.ToLower(u.Hostname())
+			cookie.HostOnly = true
+		}
+		// reject cookie of other domain
+		host := strings.ToLower(u.Hostname())
+		if host != cookie.Domain && !strings.HasSuffix(host, "."+cookie.Domain) {
+			continue
+		}
+		if cookie.Path == "" || cookie.Path[0] != '/' {
+			cookie.Path = "/"
+		}
+		if c.MaxAge > 0 {
+			cookie.Expires = now.Add(time.Duration(c.MaxAge) * time.Second)
+		}
+		deleted := c.MaxAge < 0 || (!cookie.Expires.IsZero() && cookie.Expires.Before(now))
+		jar.replace(profile.Domain, cookie, deleted)
+	}
+	// persisted jar is not overwritten by cookies of this response only when it could not be loaded
+	if _, ok := jar.loaded[profile.Domain]; ok {
+		jar.save(profile)
+	}
+}
+
+func (jar *sessionJar) Cookies(u *url.URL) (cookies []*http.Cookie) {
+	profile := GetSessionProfile(u.Host)
+	if profile == nil {
+		return
+	}
+	jar.mutex.Lock()
+	defer jar.mutex.Unlock()
+	jar.load(profile)
+
+	host := strings.ToLower(u.Hostname())
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+	now := time.Now()
+	for _, c := range jar.cookies[profile.Domain] {
+		if !c.Expires.IsZero() && c.Expires.Before(now) {
+			continue
+		}
+		if c.Secure && u.Scheme != "https" {
+			continue
+		}
+		if c.HostOnly && host != c.Domain {
+			continue
+		}
+		if !c.HostOnly && host != c.Domain && !strings.HasSuffix(host, "."+c.Domain) {
+			continue
+		}
+		if !pathMatch(path, c.Path) {
+			continue
+		}
+		cookies = append(cookies, &http.Cookie{Name: c.Name, Value: c.Value})
+	}
+	return
+}
+
+func (jar *sessionJar) replace(domain string, cookie *jarCookie, deleted bool) {
+	list := jar.cookies[domain][:0]
+	for _, c := range jar.cookies[domain] {
+		if c.Name == cookie.Name && c.Domain == cookie.Domain && c.Path == cookie.Path {
+			continue
+		}
+		list = append(list, c)
+	}
+	if !deleted {
+		list = append(list, cookie)
+	}
+	jar.cookies[domain] = list
+}
+
+// load read persisted cookies and cookies.txt of profile once, redis jar is read again after COOKIE_JAR_REFRESH.
+// Jar is loaded again at next call when it fails
+func (jar *sessionJar) load(profile *SessionProfile) {
+	loadedTime, reload := jar.loaded[profile.Domain]
+	if reload && (profile.CookieStore != COOKIE_STORE_REDIS || time.Since(loadedTime) < COOKIE_JAR_REFRESH) {
+		return
+	}
+
+	var data []byte
+	var err error
+	switch profile.CookieStore {
+	case COOKIE_STORE_REDIS:
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		var redisClient *redis.Client
+		redisClient, err = GetRedisClient()
+		if err != nil {
+			break
+		}
+		data, err = redisClient.Get(ctx, COOKIE_JAR_PREFIX+profile.Domain).Bytes()
+		if err == redis.Nil {
+			err = nil
+		}
+	case COOKIE_STORE_FILE:
+		data, err = ioutil.ReadFile(profile.CookieFile)
+		if os.IsNotExist(err) {
+			err = nil
+		}
+	}
+	if err == nil && len(data) > 0 {
+		cookies := []*jarCookie{}
+		err = json.Unmarshal(data, &cookies)
+		if err == nil {
+			jar.cookies[profile.Domain] = cookies
+		}
+	}
+	if err != nil {
+		log.Println("load cookie jar error:", profile.Domain, err)
+		return
+	}
+
+	// cookies.txt is imported at first load only, it must not replace cookies set after login
+	if profile.CookiesTxt != "" && !reload {
+		cookies, err := readCookiesTxt(profile.CookiesTxt)
+		if err != nil {
+			log.Println("import cookies.txt error:", err)
+			return
+		}
+		for _, c := range cookies {
+			jar.replace(profile.Domain, c, false)
+		}
+		// imported cookies are kept when redis jar is refreshed
+		jar.save(profile)
+	}
+	jar.loaded[profile.Domain] = time.Now()
+}
+
+// pathMatch path-match of RFC 6265 section 5.1.4, "/foo" match "/foo" and "/foo/bar", not "/foobar"
+func pathMatch(requestPath string, cookiePath string) bool {
+	if requestPath == cookiePath {
+		return true
+	}
+	if !strings.HasPrefix(requestPath, cookiePath) {
+		return false
+	}
+	return strings.HasSuffix(cookiePath, "/") || requestPath[len(cookiePath)] == '/'
+}
+
+func (jar *sessionJar) save(profile *SessionProfile) {
+	if profile.CookieStore == COOKIE_STORE_MEMORY {
+		return
+	}
+	data, err := json.Marshal(jar.cookies[profile.Domain])
+	if err != nil {
+		return
+	}
+	switch profile.CookieStore {
+	case COOKIE_STORE_REDIS:
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		var redisClient *redis.Client
+		redisClient, err = GetRedisClient()
+		if err != nil {
+			break
+		}
+		err = redisClient.Set(ctx, COOKIE_JAR_PREFIX+profile.Domain, data, 0).Err()
+	case COOKIE_STORE_FILE:
+		err = ioutil.WriteFile(profile.CookieFile, data, 0600)
+	}
+	if err != nil {
+		log.Println("save cookie jar error:", profile.Domain, err)
+	}
+}
+
+// readCookiesTxt parse Netscape cookies.txt:
+// domain, include subdomains, path, secure, expires, name, value separated by tab
+func readCookiesTxt(filePath string) (cookies []*jarCookie, err error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		httpOnly := false
+		if strings.HasPrefix(line, "#HttpOnly_") {
+			line = strings.TrimPrefix(line, "#HttpOnly_")
+			httpOnly = true
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) < 7 {
+			continue
+		}
+		cookie := &jarCookie{
+			Name:     fields[5],
+			Value:    fields[6],
+			Domain:   strings.ToLower(strings.TrimPrefix(fields[0], ".")),
+			HostOnly: strings.EqualFold(fields[1], "FALSE"),
+			Path:     fields[2],
+			Secure:   strings.EqualFold(fields[3], "TRUE"),
+			HttpOnly: httpOnly,
+		}
+		if expires, err := strconv.ParseInt(fields[4], 10, 64); err == nil && expires > 0 {
+			cookie.Expires = time.Unix(expires, 0)
+		}
+		cookies = append(cookies, cookie)
+	}
+	err = scanner.Err()
+	return
+}
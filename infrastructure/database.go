@@ -3,6 +3,7 @@ package infrastructure
 import (
 	"fmt"
 	"log"
+	"sync"
 
 	"github.com/jmoiron/sqlx"
 	// _ "github.com/lib/pq"
@@ -17,7 +18,8 @@ var (
 	dbPort   = "3306"
 	dbName   = "demo"
 
-	db *sqlx.DB
+	db      *sqlx.DB
+	dbMutex sync.Mutex
 )
 
 var schema = `CREATE TABLE IF NOT EXISTS file_upload_infos (
@@ -27,22 +29,143 @@ var schema = `CREATE TABLE IF NOT EXISTS file_upload_infos (
 	file_name VARCHAR(255),
 	ext VARCHAR(255),
 	mime_type VARCHAR(255),
+	dominant_color VARCHAR(7),
+	palette VARCHAR(255),
+	blur_hash VARCHAR(255),
 	created_time INT(11) UNSIGNED NOT NULL,
 	updated_time INT(11) UNSIGNED NOT NULL,
 	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
 	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
 );`
 
-func loadDatabase() {
-	var err error
-	db, err = sqlx.Connect("mysql", fmt.Sprintf("%v:%v@%v(%v:%v)/%v", username, password, protocol, ip, dbPort, dbName))
-	if err != nil {
-		log.Fatalln("Unable to connect database: ", err)
+var watchSchemas = []string{`CREATE TABLE IF NOT EXISTS watch_urls (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	url TEXT NOT NULL,
+	url_hash CHAR(64) NOT NULL UNIQUE,
+	interval_seconds BIGINT UNSIGNED NOT NULL,
+	next_crawl_time BIGINT UNSIGNED NOT NULL,
+	last_crawl_time BIGINT UNSIGNED NOT NULL DEFAULT 0,
+	last_error VARCHAR(1024) NOT NULL DEFAULT '',
+	last_version_id BIGINT NOT NULL DEFAULT 0,
+	created_time INT(11) UNSIGNED NOT NULL,
+	updated_time INT(11) UNSIGNED NOT NULL,
+	INDEX idx_next_crawl_time (next_crawl_time)
+);`, `CREATE TABLE IF NOT EXISTS watch_versions (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	watch_id BIGINT NOT NULL,
+	content_hash CHAR(64),
+	title TEXT,
+	description TEXT,
+	image TEXT,
+	price VARCHAR(255),
+	snapshot MEDIUMTEXT,
+	created_time INT(11) UNSIGNED NOT NULL,
+	INDEX idx_watch_id (watch_id)
+);`, `CREATE TABLE IF NOT EXISTS watch_changes (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	watch_id BIGINT NOT NULL,
+	version_id BIGINT NOT NULL,
+	field VARCHAR(64) NOT NULL,
+	old_value TEXT,
+	new_value TEXT,
+	created_time INT(11) UNSIGNED NOT NULL,
+	INDEX idx_watch_id (watch_id)
+);`}
+
+var webhookSchemas = []string{`CREATE TABLE IF NOT EXISTS webhook_endpoints (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	url TEXT NOT NULL,
+	secret VARCHAR(255) NOT NULL,
+	events VARCHAR(1024) NOT NULL DEFAULT '',
+	created_time INT(11) UNSIGNED NOT NULL,
+	updated_time INT(11) UNSIGNED NOT NULL
+);`, `CREATE TABLE IF NOT EXISTS webhook_deliveries (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	endpoint_id BIGINT NOT NULL,
+	event_id CHAR(36) NOT NULL,
+	event_type VARCHAR(64) NOT NULL,
+	payload MEDIUMTEXT NOT NULL,
+	status VARCHAR(16) NOT NULL,
+	attempts INT NOT NULL DEFAULT 0,
+	response_status INT NOT NULL DEFAULT 0,
+	last_error VARCHAR(1024) NOT NULL DEFAULT '',
+	last_attempt_time BIGINT UNSIGNED NOT NULL DEFAULT 0,
+	created_time INT(11) UNSIGNED NOT NULL,
+	updated_time INT(11) UNSIGNED NOT NULL,
+	INDEX idx_status (status),
+	INDEX idx_endpoint_id (endpoint_id)
+);`}
+
+// columnMigrations columns added after table is created, table created by older version does not have them
+var columnMigrations = []struct {
+	table      string
+	column     string
+	definition string
+}{
+	{"file_upload_infos", "dominant_color", "VARCHAR(7)"},
+	{"file_upload_infos", "palette", "VARCHAR(255)"},
+	{"file_upload_infos", "blur_hash", "VARCHAR(255)"},
+}
+
+// migrateColumns add missing columns of columnMigrations, it can run many times
+func migrateColumns(conn *sqlx.DB) error {
+	for _, migration := range columnMigrations {
+		var count int
+		err := conn.Get(&count, `SELECT COUNT(*) FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND COLUMN_NAME = ?`,
+			migration.table, migration.column)
+		if err != nil {
+			return fmt.Errorf("unable to check column %s.%s: %w", migration.table, migration.column, err)
+		}
+		if count > 0 {
+			continue
+		}
+		_, err = conn.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", migration.table, migration.column, migration.definition))
+		if err != nil {
+			return fmt.Errorf("unable to add column %s.%s: %w", migration.table, migration.column, err)
+		}
+		log.Println("added column:", migration.table, migration.column)
 	}
+	return nil
+}
 
-	db.MustExec(schema)
+// migrate create tables and add missing columns
+func migrate(conn *sqlx.DB) error {
+	if _, err := conn.Exec(schema); err != nil {
+		return err
+	}
+	if err := migrateColumns(conn); err != nil {
+		return err
+	}
+	for _, tableSchema := range append(append([]string{}, watchSchemas...), webhookSchemas...) {
+		if _, err := conn.Exec(tableSchema); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func GetDB() *sqlx.DB {
-	return db
+func loadDatabase() (*sqlx.DB, error) {
+	conn, err := sqlx.Connect("mysql", fmt.Sprintf("%v:%v@%v(%v:%v)/%v", username, password, protocol, ip, dbPort, dbName))
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect database: %w", err)
+	}
+	if err := migrate(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("unable to migrate database: %w", err)
+	}
+	return conn, nil
+}
+
+// GetDB export database, it is connected and migrated at first call, connected again at next call when it fails
+func GetDB() (*sqlx.DB, error) {
+	dbMutex.Lock()
+	defer dbMutex.Unlock()
+	if db == nil {
+		conn, err := loadDatabase()
+		if err != nil {
+			return nil, err
+		}
+		db = conn
+	}
+	return db, nil
 }
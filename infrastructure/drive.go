@@ -3,9 +3,10 @@ package infrastructure
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
-	"log"
 	"net/http"
+	"sync"
 
 	"golang.org/x/oauth2/google"
 	"golang.org/x/oauth2/jwt"
@@ -19,23 +20,27 @@ const (
 )
 
 var driveService *drive.Service
+var driveMutex sync.Mutex
 var rootFolderDrive = "1PjsulGwMg2TuTwFJyqUWlElIjd0xf0YJ"
 
-func loadDriveService() {
-	var err error
-	client := getClientDrive("./infrastructure/drive_secret.json")
+func loadDriveService() (*drive.Service, error) {
+	client, err := getClientDrive("./infrastructure/drive_secret.json")
+	if err != nil {
+		return nil, err
+	}
 
-	driveService, err = drive.New(client)
+	service, err := drive.New(client)
 
 	if err != nil {
-		log.Fatal("Unable to retrieve drive client: ", err)
+		return nil, fmt.Errorf("unable to retrieve drive client: %w", err)
 	}
+	return service, nil
 }
 
-func getClientDrive(secretFile string) *http.Client {
+func getClientDrive(secretFile string) (*http.Client, error) {
 	b, err := ioutil.ReadFile(secretFile)
 	if err != nil {
-		log.Fatal("error while reading the credential file", err)
+		return nil, fmt.Errorf("error while reading the credential file: %w", err)
 	}
 	var s = struct {
 		Email      string `json:"client_email"`
@@ -54,12 +59,21 @@ func getClientDrive(secretFile string) *http.Client {
 
 	client := config.Client(context.Background())
 
-	return client
+	return client, nil
 }
 
-// GetDriveService export drive service
-func GetDriveService() *drive.Service {
-	return driveService
+// GetDriveService export drive service, it is loaded at first call and loaded again at next call when it fails
+func GetDriveService() (*drive.Service, error) {
+	driveMutex.Lock()
+	defer driveMutex.Unlock()
+	if driveService == nil {
+		service, err := loadDriveService()
+		if err != nil {
+			return nil, err
+		}
+		driveService = service
+	}
+	return driveService, nil
 }
 
 // GetRootDrive export root drive string
@@ -67,10 +81,8 @@ func GetRootFolderDrive() string {
 	return rootFolderDrive
 }
 
+// init only build http client, drive, aws, database and redis are connected at first use
+// so help and usage error of command do not need them
 func init() {
-	loadDriveService()
-	loadAwsService()
-	loadDatabase()
-	loadRedis()
-	log.Println("Load parameters succussful!")
+	loadHttpClient()
 }
@@ -2,30 +2,44 @@ package infrastructure
 
 import (
 	"context"
-	"log"
+	"fmt"
+	"sync"
 	"time"
 
 	"github.com/go-redis/redis/v8"
 )
 
 var (
-	client *redis.Client
+	client     *redis.Client
+	redisMutex sync.Mutex
 )
 
-func loadRedis() {
-	client = redis.NewClient(&redis.Options{
+func connectRedis() (*redis.Client, error) {
+	redisClient := redis.NewClient(&redis.Options{
 		Addr:     "localhost:6379",
 		Password: "",
 		DB:       0,
 	})
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
-	err := client.Ping(ctx).Err()
+	err := redisClient.Ping(ctx).Err()
 	if err != nil {
-		log.Fatal("Unable to connect redis: ", err)
+		redisClient.Close()
+		return nil, fmt.Errorf("unable to connect redis: %w", err)
 	}
+	return redisClient, nil
 }
 
-func GetRedisClient() *redis.Client {
-	return client
+// GetRedisClient export redis client, it is connected at first call and connected again at next call when it fails
+func GetRedisClient() (*redis.Client, error) {
+	redisMutex.Lock()
+	defer redisMutex.Unlock()
+	if client == nil {
+		redisClient, err := connectRedis()
+		if err != nil {
+			return nil, err
+		}
+		client = redisClient
+	}
+	return client, nil
 }
@@ -0,0 +1,188 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// UrlPolicy restrict url which crawler can fetch, to protect internal network from user input (SSRF)
+type UrlPolicy struct {
+	AllowedSchemes []string `yaml:"allowedSchemes"`
+	// AllowedPorts empty is allow all port
+	AllowedPorts []int `yaml:"allowedPorts"`
+	// AllowPrivateNetwork allow loopback, private, link-local, metadata ip (only for local testing)
+	AllowPrivateNetwork bool `yaml:"allowPrivateNetwork"`
+	// BlockedCidrs blocked in addition to default ranges
+	BlockedCidrs []string `yaml:"blockedCidrs"`
+}
+
+var ErrUrlNotAllowed = errors.New("url is not allowed")
+
+var defaultBlockedCidrs = []string{
+	"0.0.0.0/8",       // this network
+	"10.0.0.0/8",      // private
+	"100.64.0.0/10",   // carrier-grade NAT
+	"127.0.0.0/8",     // loopback
+	"169.254.0.0/16",  // link-local, cloud metadata
+	"172.16.0.0/12",   // private
+	"192.0.0.0/24",    // IETF protocol assignments
+	"192.0.2.0/24",    // TEST-NET-1
+	"192.168.0.0/16",  // private
+	"198.18.0.0/15",   // benchmarking
+	"198.51.100.0/24", // TEST-NET-2
+	"203.0.113.0/24",  // TEST-NET-3
+	"224.0.0.0/4",     // multicast
+	"240.0.0.0/4",     // reserved, broadcast
+	"::/128",          // unspecified
+	"::1/128",         // loopback
+	"64:ff9b::/96",    // NAT64, can map to private ipv4
+	"2001::/32",       // Teredo, embeds ipv4 of server and client
+	"2002::/16",       // 6to4, embeds any ipv4
+	"fc00::/7",        // unique local, include aws metadata fd00:ec2::254
+	"fe80::/10",       // link-local
+	"ff00::/8",        // multicast
+}
+
+var blockedNets []*net.IPNet
+
+func loadUrlPolicy() {
+	blockedNets = nil
+	for _, cidr := range append(defaultBlockedCidrs, fetcherConfig.UrlPolicy.BlockedCidrs...) {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			continue
+		}
+		blockedNets = append(blockedNets, ipNet)
+	}
+}
+
+// CheckUrl check scheme, port and resolved ip of url
+func CheckUrl(ctx context.Context, u *url.URL) error {
+	policy := fetcherConfig.UrlPolicy
+	if !isAllowedScheme(policy, u.Scheme) {
+		return fmt.Errorf("%w: scheme %q", ErrUrlNotAllowed, u.Scheme)
+	}
+	port := urlPort(u)
+	if !isAllowedPort(policy, port) {
+		return fmt.Errorf("%w: port %d", ErrUrlNotAllowed, port)
+	}
+	if policy.AllowPrivateNetwork {
+		return nil
+	}
+	host := u.Hostname()
+	if host == "" {
+		return fmt.Errorf("%w: empty host", ErrUrlNotAllowed)
+	}
+	// request via proxy is resolved by proxy, check it before sending
+	ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return err
+	}
+	for _, ip := range ips {
+		if IsBlockedIP(ip.IP) {
+			return fmt.Errorf("%w: %s resolve to blocked ip %s", ErrUrlNotAllowed, host, ip.IP)
+		}
+	}
+	return nil
+}
+
+// IsBlockedIP check ip is loopback, private, link-local, metadata... or in BlockedCidrs
+func IsBlockedIP(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	for _, ipNet := range blockedNets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// dialControl check ip when connecting, so hostname can not be rebinded to blocked ip after CheckUrl
+func dialControl(network, address string, c syscall.RawConn) error {
+	if fetcherConfig.UrlPolicy.AllowPrivateNetwork {
+		return nil
+	}
+	host, portValue, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if isProxyAddress(host, portValue) {
+		return nil
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || IsBlockedIP(ip) {
+		return fmt.Errorf("%w: connect to blocked ip %s", ErrUrlNotAllowed, host)
+	}
+	port, _ := strconv.Atoi(portValue)
+	if !isAllowedPort(fetcherConfig.UrlPolicy, port) {
+		return fmt.Errorf("%w: port %d", ErrUrlNotAllowed, port)
+	}
+	return nil
+}
+
+// isProxyAddress proxy is configured by us, it can be in private network
+func isProxyAddress(ip string, port string) bool {
+	if fetcherConfig.ProxyUrl == "" {
+		return false
+	}
+	proxyUrl, err := url.Parse(fetcherConfig.ProxyUrl)
+	if err != nil || strconv.Itoa(urlPort(proxyUrl)) != port {
+		return false
+	}
+	if proxyUrl.Hostname() == ip {
+		return true
+	}
+	ips, err := net.LookupHost(proxyUrl.Hostname())
+	if err != nil {
+		return false
+	}
+	for _, v := range ips {
+		if v == ip {
+			return true
+		}
+	}
+	return false
+}
+
+func isAllowedScheme(policy UrlPolicy, scheme string) bool {
+	for _, v := range policy.AllowedSchemes {
+		if strings.EqualFold(v, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+func isAllowedPort(policy UrlPolicy, port int) bool {
+	if len(policy.AllowedPorts) == 0 {
+		return true
+	}
+	for _, v := range policy.AllowedPorts {
+		if v == port {
+			return true
+		}
+	}
+	return false
+}
+
+func urlPort(u *url.URL) int {
+	if port, err := strconv.Atoi(u.Port()); err == nil {
+		return port
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "https":
+		return 443
+	case "socks5":
+		return 1080
+	default:
+		return 80
+	}
+}
@@ -0,0 +1,128 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+	"testing"
+)
+
+// setUrlPolicy use default fetcher config with policy and proxy in test
+func setUrlPolicy(t *testing.T, policy UrlPolicy, proxyUrl string) {
+	old := fetcherConfig
+	t.Cleanup(func() {
+		fetcherConfig = old
+		loadUrlPolicy()
+	})
+	fetcherConfig.UrlPolicy = policy
+	fetcherConfig.ProxyUrl = proxyUrl
+	loadUrlPolicy()
+}
+
+func TestIsBlockedIP(t *testing.T) {
+	setUrlPolicy(t, UrlPolicy{BlockedCidrs: []string{"8.8.4.0/24"}}, "")
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"93.184.216.34", false},
+		{"2606:2800:220:1:248:1893:25c8:1946", false},
+		{"127.0.0.1", true},
+		{"10.1.2.3", true},
+		{"172.16.0.1", true},
+		{"192.168.1.1", true},
+		{"169.254.169.254", true},
+		{"100.64.0.1", true},
+		{"0.0.0.0", true},
+		{"::1", true},
+		{"::", true},
+		{"fd00:ec2::254", true},
+		{"fe80::1", true},
+		{"::ffff:169.254.169.254", true},
+		{"::ffff:127.0.0.1", true},
+		{"::ffff:93.184.216.34", false},
+		{"64:ff9b::a9fe:a9fe", true},
+		{"2002:a9fe:a9fe::1", true},
+		{"2002:5db8:d822::1", true},
+		{"2001:0:4136:e378:8000:63bf:3fff:fdd2", true},
+		{"2001:db9::1", false},
+		{"8.8.4.4", true},
+		{"8.8.8.8", false},
+	}
+	for _, test := range tests {
+		if got := IsBlockedIP(net.ParseIP(test.ip)); got != test.want {
+			t.Errorf("IsBlockedIP(%q) = %v, want %v", test.ip, got, test.want)
+		}
+	}
+}
+
+func TestCheckUrl(t *testing.T) {
+	tests := []struct {
+		rawUrl  string
+		private bool
+		wantErr bool
+	}{
+		{"https://93.184.216.34/a", false, false},
+		{"http://93.184.216.34:8080/a", false, false},
+		{"https://93.184.216.34:22/a", false, true},
+		{"http://93.184.216.34:443/a", false, false},
+		{"ftp://93.184.216.34/a", false, true},
+		{"http://169.254.169.254/latest/meta-data", false, true},
+		{"http://[::ffff:169.254.169.254]/latest/meta-data", false, true},
+		{"http://[2002:a9fe:a9fe::1]/", false, true},
+		{"http://[2001:0:4136:e378:8000:63bf:3fff:fdd2]/", false, true},
+		{"http://127.0.0.1/", false, true},
+		{"http://127.0.0.1/", true, false},
+		{"http://127.0.0.1:22/", true, true},
+	}
+	for _, test := range tests {
+		setUrlPolicy(t, UrlPolicy{
+			AllowedSchemes:      []string{"http", "https"},
+			AllowedPorts:        []int{80, 443, 8080, 8443},
+			AllowPrivateNetwork: test.private,
+		}, "")
+		u, err := url.Parse(test.rawUrl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = CheckUrl(context.Background(), u)
+		if (err != nil) != test.wantErr {
+			t.Errorf("CheckUrl(%q) private=%v error = %v, want error %v", test.rawUrl, test.private, err, test.wantErr)
+		}
+		if err != nil && !errors.Is(err, ErrUrlNotAllowed) {
+			t.Errorf("CheckUrl(%q) error = %v, want ErrUrlNotAllowed", test.rawUrl, err)
+		}
+	}
+}
+
+func TestDialControl(t *testing.T) {
+	tests := []struct {
+		address  string
+		proxyUrl string
+		wantErr  bool
+	}{
+		{"93.184.216.34:443", "", false},
+		{"93.184.216.34:8080", "", false},
+		{"93.184.216.34:25", "", true},
+		{"127.0.0.1:443", "", true},
+		{"[::ffff:169.254.169.254]:80", "", true},
+		{"[2002:a9fe:a9fe::1]:80", "", true},
+		{"[2001:0:4136:e378:8000:63bf:3fff:fdd2]:80", "", true},
+		{"127.0.0.1:3128", "http://127.0.0.1:3128", false},
+		{"10.0.0.5:1080", "socks5://10.0.0.5", false},
+		{"127.0.0.1:3129", "http://127.0.0.1:3128", true},
+		{"127.0.0.2:3128", "http://127.0.0.1:3128", true},
+		{"not-an-address", "", true},
+	}
+	for _, test := range tests {
+		setUrlPolicy(t, UrlPolicy{
+			AllowedSchemes: []string{"http", "https"},
+			AllowedPorts:   []int{80, 443, 8080, 8443},
+		}, test.proxyUrl)
+		err := dialControl("tcp", test.address, nil)
+		if (err != nil) != test.wantErr {
+			t.Errorf("dialControl(%q) proxy=%q error = %v, want error %v", test.address, test.proxyUrl, err, test.wantErr)
+		}
+	}
+}
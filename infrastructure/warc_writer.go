@@ -0,0 +1,240 @@
+package infrastructure
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/base32"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WarcConfig write every request/response of shared http client to WARC 1.1 files
+type WarcConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Dir     string `yaml:"dir"`
+	Prefix  string `yaml:"prefix"`
+	// MaxFileSize file is rotated when it is larger than this size
+	MaxFileSize int64 `yaml:"maxFileSize"`
+	// UploadToS3 upload rotated file to bucket
+	UploadToS3 bool `yaml:"uploadToS3"`
+}
+
+// OnWarcFileClosed called with path of WARC file after it is rotated or closed
+var OnWarcFileClosed func(filePath string)
+
+type warcWriter struct {
+	mutex    sync.Mutex
+	file     *os.File
+	size     int64
+	serial   int
+	infoId   string
+	filePath string
+	// uploads OnWarcFileClosed of rotated files, waited by CloseWarc
+	uploads sync.WaitGroup
+}
+
+var warc = &warcWriter{}
+
+// warcTransport record request and response to WARC file
+type warcTransport struct {
+	transport http.RoundTripper
+}
+
+func (t *warcTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var remoteIP string
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if addr, ok := info.Conn.RemoteAddr().(*net.TCPAddr); ok {
+				remoteIP = addr.IP.String()
+			}
+		},
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+	date := time.Now().UTC()
+	res, err := t.transport.RoundTrip(req)
+	if err != nil {
+		return res, err
+	}
+
+	// body is read to write record, then it can be read again by caller.
+	// body larger than max body size is truncated in record, caller still get error when reading it
+	limit := fetcherConfig.MaxBodySize
+	var body []byte
+	if limit > 0 {
+		body, err = ioutil.ReadAll(io.LimitReader(res.Body, limit+1))
+	} else {
+		body, err = ioutil.ReadAll(res.Body)
+	}
+	res.Body.Close()
+	if err != nil {
+		res.Body = ioutil.NopCloser(io.MultiReader(bytes.NewReader(body), errReader{err}))
+	} else {
+		res.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	truncated := err != nil
+	if limit > 0 && int64(len(body)) > limit {
+		body, truncated = body[:limit], true
+	}
+
+	errWarc := warc.writeExchange(req, res, body, truncated, remoteIP, date)
+	if errWarc != nil {
+		log.Println("write warc error:", errWarc)
+	}
+	return res, nil
+}
+
+type errReader struct{ err error }
+
+func (r errReader) Read([]byte) (int, error) { return 0, r.err }
+
+func (w *warcWriter) writeExchange(req *http.Request, res *http.Response, body []byte, truncated bool, remoteIP string, date time.Time) error {
+	var requestBlock bytes.Buffer
+	fmt.Fprintf(&requestBlock, "%s %s %s\r\n", req.Method, req.URL.RequestURI(), res.Proto)
+	fmt.Fprintf(&requestBlock, "Host: %s\r\n", req.URL.Host)
+	req.Header.Write(&requestBlock)
+	requestBlock.WriteString("\r\n")
+	if req.GetBody != nil {
+		if requestBody, err := req.GetBody(); err == nil {
+			io.Copy(&requestBlock, requestBody)
+			requestBody.Close()
+		}
+	}
+
+	var responseBlock bytes.Buffer
+	fmt.Fprintf(&responseBlock, "%s %s\r\n", res.Proto, res.Status)
+	res.Header.Write(&responseBlock)
+	responseBlock.WriteString("\r\n")
+	responseBlock.Write(body)
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if err := w.open(); err != nil {
+		return err
+	}
+	responseId := newRecordId()
+	responseHeaders := [][2]string{
+		{"WARC-Target-URI", req.URL.String()},
+		{"WARC-Warcinfo-ID", w.infoId},
+		{"WARC-Payload-Digest", warcDigest(body)},
+	}
+	if remoteIP != "" {
+		responseHeaders = append(responseHeaders, [2]string{"WARC-IP-Address", remoteIP})
+	}
+	if truncated {
+		responseHeaders = append(responseHeaders, [2]string{"WARC-Truncated", "length"})
+	}
+	if err := w.writeRecord("response", responseId, date, "application/http;msgtype=response", responseHeaders, responseBlock.Bytes()); err != nil {
+		return err
+	}
+	err := w.writeRecord("request", newRecordId(), date, "application/http;msgtype=request", [][2]string{
+		{"WARC-Target-URI", req.URL.String()},
+		{"WARC-Warcinfo-ID", w.infoId},
+		{"WARC-Concurrent-To", responseId},
+	}, requestBlock.Bytes())
+	if err != nil {
+		return err
+	}
+	if fetcherConfig.Warc.MaxFileSize > 0 && w.size >= fetcherConfig.Warc.MaxFileSize {
+		w.close(false)
+	}
+	return nil
+}
+
+// open create new WARC file with warcinfo record if there is no opened file
+func (w *warcWriter) open() error {
+	if w.file != nil {
+		return nil
+	}
+	config := fetcherConfig.Warc
+	if err := os.MkdirAll(config.Dir, 0755); err != nil {
+		return err
+	}
+	w.serial++
+	fileName := fmt.Sprintf("%s-%s-%05d.warc", config.Prefix, time.Now().UTC().Format("20060102150405"), w.serial)
+	file, err := os.Create(filepath.Join(config.Dir, fileName))
+	if err != nil {
+		return err
+	}
+	w.file, w.size, w.filePath = file, 0, file.Name()
+	w.infoId = newRecordId()
+	hostname, _ := os.Hostname()
+	info := fmt.Sprintf("software: crawlweb\r\nhostname: %s\r\nformat: WARC File Format 1.1\r\nconformsTo: http://iipc.github.io/warc-specifications/specifications/warc-format/warc-1.1/\r\n", hostname)
+	return w.writeRecord("warcinfo", w.infoId, time.Now().UTC(), "application/warc-fields", [][2]string{
+		{"WARC-Filename", fileName},
+	}, []byte(info))
+}
+
+func (w *warcWriter) writeRecord(recordType string, recordId string, date time.Time, contentType string, headers [][2]string, block []byte) error {
+	var record bytes.Buffer
+	record.WriteString("WARC/1.1\r\n")
+	fmt.Fprintf(&record, "WARC-Type: %s\r\n", recordType)
+	fmt.Fprintf(&record, "WARC-Record-ID: %s\r\n", recordId)
+	fmt.Fprintf(&record, "WARC-Date: %s\r\n", date.Format("2006-01-02T15:04:05.000000Z"))
+	for _, header := range headers {
+		fmt.Fprintf(&record, "%s: %s\r\n", header[0], strings.NewReplacer("\r", "", "\n", "").Replace(header[1]))
+	}
+	fmt.Fprintf(&record, "Content-Type: %s\r\n", contentType)
+	fmt.Fprintf(&record, "WARC-Block-Digest: %s\r\n", warcDigest(block))
+	fmt.Fprintf(&record, "Content-Length: %d\r\n", len(block))
+	record.WriteString("\r\n")
+	record.Write(block)
+	record.WriteString("\r\n\r\n")
+
+	n, err := w.file.Write(record.Bytes())
+	w.size += int64(n)
+	return err
+}
+
+// close current file, wait for OnWarcFileClosed when wait is true
+func (w *warcWriter) close(wait bool) {
+	if w.file == nil {
+		return
+	}
+	err := w.file.Close()
+	if err != nil {
+		log.Println("close warc file error:", err)
+	}
+	filePath := w.filePath
+	w.file = nil
+	if OnWarcFileClosed == nil {
+		return
+	}
+	if wait {
+		OnWarcFileClosed(filePath)
+	} else {
+		w.uploads.Add(1)
+		go func() {
+			defer w.uploads.Done()
+			OnWarcFileClosed(filePath)
+		}()
+	}
+}
+
+// CloseWarc close current WARC file and wait for OnWarcFileClosed of all files, should be called before exit
+func CloseWarc() {
+	warc.mutex.Lock()
+	warc.close(true)
+	warc.mutex.Unlock()
+	warc.uploads.Wait()
+}
+
+func newRecordId() string {
+	return "<urn:uuid:" + uuid.New().String() + ">"
+}
+
+func warcDigest(data []byte) string {
+	sum := sha1.Sum(data)
+	return "sha1:" + base32.StdEncoding.EncodeToString(sum[:])
+}
@@ -3,26 +3,210 @@ package model
 import "time"
 
 type OpenGraphModel struct {
-	Type        string
-	Title       string
-	SiteName    string
-	Description string
-	Author      string
-	Image       string
-	Url         string
-	Filename    string
-	Etag        string
+	Type        string `json:"type"`
+	Title       string `json:"title"`
+	SiteName    string `json:"siteName"`
+	Description string `json:"description"`
+	Author      string `json:"author"`
+	Image       string `json:"image"`
+	Url         string `json:"url"`
+	Favicon     string `json:"favicon"`
+	Filename    string `json:"filename"`
+	Etag        string `json:"etag"`
+	PreviewCard string `json:"previewCard"`
+	// Price, Currency of product page
+	Price    string `json:"price"`
+	Currency string `json:"currency"`
+	// Content text of article, stitched from all pages of paginated article
+	Content      string `json:"content"`
+	ContentPages int    `json:"contentPages"`
+	// ContentHash sha256 of text content of page, used for detecting changes
+	ContentHash string `json:"contentHash"`
+	// FinalUrl url after following all redirects
+	FinalUrl string `json:"finalUrl"`
+	// CanonicalUrl canonical form of FinalUrl, without tracking params
+	CanonicalUrl  string        `json:"canonicalUrl"`
+	RedirectChain []RedirectHop `json:"redirectChain"`
+	// number of attempts to fetch page and image
+	FetchAttempts      int `json:"fetchAttempts"`
+	ImageFetchAttempts int `json:"imageFetchAttempts"`
+	// Links links of page, only used for following links when crawling site
+	Links []string `json:"-"`
+	ImageColorInfo
+	MediaInfo
+}
+
+// MediaInfo info of url which is not html page (image, pdf, audio, video, other file)
+type MediaInfo struct {
+	ContentType   string `json:"contentType"`
+	ContentLength int64  `json:"contentLength"`
+	Width         int    `json:"width"`
+	Height        int    `json:"height"`
+	PageCount     int    `json:"pageCount"`
+}
+
+// ImageColorInfo colors of image, used for placeholder while image is loading
+type ImageColorInfo struct {
+	DominantColor string   `json:"dominantColor"`
+	Palette       []string `json:"palette"`
+	BlurHash      string   `json:"blurHash"`
+}
+
+// CrawlRecord result of one url when crawling many urls, Error is not empty when crawling fail
+type CrawlRecord struct {
+	Url    string          `json:"url"`
+	Depth  int             `json:"depth"`
+	Error  string          `json:"error,omitempty"`
+	Result *OpenGraphModel `json:"result,omitempty"`
+}
+
+// BatchRecord result of one url of batch, Line is line number of url in input
+type BatchRecord struct {
+	Line       int             `json:"line"`
+	Url        string          `json:"url"`
+	DurationMs int64           `json:"durationMs"`
+	Error      string          `json:"error,omitempty"`
+	Result     *OpenGraphModel `json:"result,omitempty"`
+}
+
+// WatchUrl url re-crawled every IntervalSeconds for detecting changes
+type WatchUrl struct {
+	Id              int64  `json:"id" db:"id"`
+	Url             string `json:"url" db:"url"`
+	UrlHash         string `json:"urlHash" db:"url_hash"`
+	IntervalSeconds int64  `json:"intervalSeconds" db:"interval_seconds"`
+	NextCrawlTime   int64  `json:"nextCrawlTime" db:"next_crawl_time"`
+	LastCrawlTime   int64  `json:"lastCrawlTime" db:"last_crawl_time"`
+	LastError       string `json:"lastError" db:"last_error"`
+	LastVersionId   int64  `json:"lastVersionId" db:"last_version_id"`
+	CreatedTime     int64  `json:"createdTime" db:"created_time"`
+	UpdateTime      int64  `json:"updateTime" db:"updated_time"`
+}
+
+// WatchVersion snapshot of watched url, new version is stored only when content or fields change
+type WatchVersion struct {
+	Id          int64  `json:"id" db:"id"`
+	WatchId     int64  `json:"watchId" db:"watch_id"`
+	ContentHash string `json:"contentHash" db:"content_hash"`
+	Title       string `json:"title" db:"title"`
+	Description string `json:"description" db:"description"`
+	Image       string `json:"image" db:"image"`
+	Price       string `json:"price" db:"price"`
+	// Snapshot json of OpenGraphModel
+	Snapshot    string `json:"snapshot" db:"snapshot"`
+	CreatedTime int64  `json:"createdTime" db:"created_time"`
+}
+
+// FieldChange change of one field between 2 versions
+type FieldChange struct {
+	Field    string `json:"field" db:"field"`
+	OldValue string `json:"oldValue" db:"old_value"`
+	NewValue string `json:"newValue" db:"new_value"`
+}
+
+// WatchChangeEvent emitted when watched fields of url change
+type WatchChangeEvent struct {
+	WatchId       int64         `json:"watchId"`
+	Url           string        `json:"url"`
+	VersionId     int64         `json:"versionId"`
+	PrevVersionId int64         `json:"prevVersionId"`
+	Changes       []FieldChange `json:"changes"`
+	DetectedTime  int64         `json:"detectedTime"`
+}
+
+// RedirectHop one redirect of crawling url, Type is http, meta-refresh or javascript
+type RedirectHop struct {
+	Type       string `json:"type"`
+	Url        string `json:"url"`
+	StatusCode int    `json:"statusCode"`
+	Location   string `json:"location"`
 }
 
 type FileUploadInfo struct {
-	Id          int        `json:"id" db:"id"`
-	FileId      int64      `json:"fileId" db:"file_id"`
-	FileSize    int64      `json:"fileSize" db:"file_size"`
-	FileName    string     `json:"fileName" db:"file_name"`
-	Ext         string     `json:"ext" db:"ext"`
-	MimeType    string     `json:"mimeType" db:"mime_type"`
-	CreatedTime int64      `json:"createdTime" db:"created_time"`
-	UpdateTime  int64      `json:"updateTime" db:"updated_time"`
-	CreatedAt   *time.Time `json:"createdAt" db:"created_at"`
-	UpdatedAt   *time.Time `json:"updatedAt" db:"updated_at"`
+	Id            int        `json:"id" db:"id"`
+	FileId        int64      `json:"fileId" db:"file_id"`
+	FileSize      int64      `json:"fileSize" db:"file_size"`
+	FileName      string     `json:"fileName" db:"file_name"`
+	Ext           string     `json:"ext" db:"ext"`
+	MimeType      string     `json:"mimeType" db:"mime_type"`
+	DominantColor string     `json:"dominantColor" db:"dominant_color"`
+	Palette       string     `json:"palette" db:"palette"`
+	BlurHash      string     `json:"blurHash" db:"blur_hash"`
+	CreatedTime   int64      `json:"createdTime" db:"created_time"`
+	UpdateTime    int64      `json:"updateTime" db:"updated_time"`
+	CreatedAt     *time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt     *time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// WebhookEndpoint url receiving events, Events is comma separated event types, empty is all events
+type WebhookEndpoint struct {
+	Id          int64  `json:"id" db:"id"`
+	Url         string `json:"url" db:"url"`
+	Secret      string `json:"secret" db:"secret"`
+	Events      string `json:"events" db:"events"`
+	CreatedTime int64  `json:"createdTime" db:"created_time"`
+	UpdateTime  int64  `json:"updateTime" db:"updated_time"`
+}
+
+// WebhookDelivery one event sent to one endpoint, Payload is kept for replay
+type WebhookDelivery struct {
+	Id              int64  `json:"id" db:"id"`
+	EndpointId      int64  `json:"endpointId" db:"endpoint_id"`
+	EventId         string `json:"eventId" db:"event_id"`
+	EventType       string `json:"eventType" db:"event_type"`
+	Payload         string `json:"payload" db:"payload"`
+	Status          string `json:"status" db:"status"`
+	Attempts        int    `json:"attempts" db:"attempts"`
+	ResponseStatus  int    `json:"responseStatus" db:"response_status"`
+	LastError       string `json:"lastError" db:"last_error"`
+	LastAttemptTime int64  `json:"lastAttemptTime" db:"last_attempt_time"`
+	CreatedTime     int64  `json:"createdTime" db:"created_time"`
+	UpdateTime      int64  `json:"updateTime" db:"updated_time"`
+}
+
+// WebhookEvent body of webhook request
+type WebhookEvent struct {
+	Id          string      `json:"id"`
+	Type        string      `json:"type"`
+	CreatedTime int64       `json:"createdTime"`
+	Data        interface{} `json:"data"`
+}
+
+// CrawlEventData data of crawl.completed and crawl.failed events
+type CrawlEventData struct {
+	Url    string          `json:"url"`
+	Error  string          `json:"error,omitempty"`
+	Result *OpenGraphModel `json:"result,omitempty"`
+}
+
+// JobEventData data of batch.completed and site_crawl.completed events
+type JobEventData struct {
+	Job        string `json:"job,omitempty"`
+	Output     string `json:"output,omitempty"`
+	Total      int    `json:"total"`
+	Succeeded  int    `json:"succeeded"`
+	Failed     int    `json:"failed"`
+	DurationMs int64  `json:"durationMs"`
+	Error      string `json:"error,omitempty"`
+}
+
+// UploadEventData data of upload.completed and upload.failed events
+type UploadEventData struct {
+	Source   string `json:"source"`
+	Storage  string `json:"storage"`
+	Filename string `json:"filename,omitempty"`
+	Etag     string `json:"etag,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// StreamJob job read from redis stream, Type is crawl or upload, JobId is id given by producer for matching result
+type StreamJob struct {
+	MessageId  string `json:"messageId"`
+	JobId      string `json:"jobId"`
+	Type       string `json:"type"`
+	Url        string `json:"url"`
+	Storage    string `json:"storage"`
+	SkipUpload bool   `json:"skipUpload"`
+	// Deliveries number of times job is delivered to consumers, include this time
+	Deliveries int64 `json:"deliveries"`
 }
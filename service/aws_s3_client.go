@@ -6,18 +6,33 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"net/http"
 	"sort"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/s3"
 )
 
+// newS3 s3 client of shared aws session
+func newS3() (*s3.S3, error) {
+	awsSession, err := infrastructure.GetAwsSession()
+	if err != nil {
+		return nil, err
+	}
+	return s3.New(awsSession), nil
+}
+
 func GetPresignedUrlUploadFile(bucketname, filename string) string {
 	if filename == "" {
 		return ""
 	}
-	svc := s3.New(infrastructure.GetAwsSession())
+	svc, err := newS3()
+	if err != nil {
+		fmt.Println("Failed to generate a pre-signed url: ", err)
+		return ""
+	}
 	res, _ := svc.PutObjectRequest(&s3.PutObjectInput{
 		Bucket: aws.String(bucketname),
 		Key:    aws.String(filename),
@@ -36,7 +51,10 @@ func GetPresignedUrlUploadLargeFile(bucketname, filename string, sizeFile int64,
 	if filename == "" {
 		return "", nil, errors.New("filename is EMPTY")
 	}
-	svc := s3.New(infrastructure.GetAwsSession())
+	svc, err := newS3()
+	if err != nil {
+		return "", nil, err
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Minute)
 	defer cancel()
 	out, err := svc.CreateMultipartUploadWithContext(ctx, &s3.CreateMultipartUploadInput{
@@ -88,7 +106,10 @@ func CompleteMultipartUpload(bucketname, filename, uploadId string, listcomplete
 	sort.Slice(listcompletedParts, func(i, j int) bool {
 		return int(*listcompletedParts[i].PartNumber) < int(*listcompletedParts[j].PartNumber)
 	})
-	svc := s3.New(infrastructure.GetAwsSession())
+	svc, err := newS3()
+	if err != nil {
+		return "", err
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
 	defer cancel()
 	out, err := svc.CompleteMultipartUploadWithContext(ctx, &s3.CompleteMultipartUploadInput{
@@ -106,11 +127,39 @@ func CompleteMultipartUpload(bucketname, filename, uploadId string, listcomplete
 	return *out.ETag, nil
 }
 
+// ErrObjectNotFound object is not in bucket
+var ErrObjectNotFound = errors.New("object not found")
+
+// GetObjectInfo size and content type of object in bucket
+func GetObjectInfo(bucketname, filename string) (size int64, contentType string, err error) {
+	svc, err := newS3()
+	if err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	out, err := svc.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucketname),
+		Key:    aws.String(filename),
+	})
+	if aerr, ok := err.(awserr.RequestFailure); ok && aerr.StatusCode() == http.StatusNotFound {
+		return 0, "", ErrObjectNotFound
+	}
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	return aws.Int64Value(out.ContentLength), aws.StringValue(out.ContentType), nil
+}
+
 func AbortMultipartUpload(bucketname, filename, uploadId string) error {
-	svc := s3.New(infrastructure.GetAwsSession())
+	svc, err := newS3()
+	if err != nil {
+		return err
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
 	defer cancel()
-	_, err := svc.AbortMultipartUploadWithContext(ctx, &s3.AbortMultipartUploadInput{
+	_, err = svc.AbortMultipartUploadWithContext(ctx, &s3.AbortMultipartUploadInput{
 		Bucket:   aws.String(bucketname),
 		Key:      aws.String(filename),
 		UploadId: aws.String(uploadId),
@@ -5,10 +5,10 @@ import (
 	"context"
 	"crawlweb/infrastructure"
 	"crawlweb/model"
-	"crawlweb/utils"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"net/http"
@@ -16,6 +16,7 @@ import (
 	"path"
 	"path/filepath"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -27,9 +28,12 @@ const (
 	PART_SIZE       = 5_242_880 // 5_242_880 minimim
 	RETRIES         = 2
 	LARGE_FILE_SIZE = 20_000_000
+
+	// UPLOAD_PART_CONCURRENCY parts of presigned multipart upload uploaded at same time
+	UPLOAD_PART_CONCURRENCY = 4
 )
 
-func UploadFileToBucket(url string, mimeType string) (s3Filename string, etag string, err error) {
+func UploadFileToBucket(url string, mimeType string) (s3Filename string, etag string, colorInfo model.ImageColorInfo, downloadAttempts int, err error) {
 	if url == "" {
 		err = errors.New("url is EMPTY")
 		return
@@ -39,11 +43,15 @@ func UploadFileToBucket(url string, mimeType string) (s3Filename string, etag st
 	// read File
 	fileName := GenCode() + ".jpg"
 	filePath := "./temp/" + fileName
-	err = DownloadFile(url, filePath)
+	downloadAttempts, err = DownloadImageWithRetry(url, filePath)
 	if err != nil {
 		log.Println("Error:", err.Error())
 		return
 	}
+	colorInfo, err = GetImageColorInfo(filePath)
+	if err != nil {
+		log.Println("get image color fail:", err)
+	}
 	tempFile, err := os.Open(filePath)
 	if err != nil {
 		log.Printf("Read file error: %+v\n", err)
@@ -64,10 +72,13 @@ func UploadFileToBucket(url string, mimeType string) (s3Filename string, etag st
 	}
 
 	err = Insert(model.FileUploadInfo{
-		FileSize: fileSize,
-		FileName: fileName,
-		Ext:      filepath.Ext(fileName),
-		MimeType: mimeType,
+		FileSize:      fileSize,
+		FileName:      fileName,
+		Ext:           filepath.Ext(fileName),
+		MimeType:      mimeType,
+		DominantColor: colorInfo.DominantColor,
+		Palette:       strings.Join(colorInfo.Palette, ","),
+		BlurHash:      colorInfo.BlurHash,
 	})
 	if err != nil {
 		log.Println("insert file to db fail:", err)
@@ -76,6 +87,23 @@ func UploadFileToBucket(url string, mimeType string) (s3Filename string, etag st
 	return
 }
 
+// UploadLocalFile upload local file to bucket, large file is uploaded by multipart
+func UploadLocalFile(filePath string) (s3Filename string, etag string, err error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	stats, err := file.Stat()
+	if err != nil {
+		return
+	}
+	if stats.Size() <= LARGE_FILE_SIZE {
+		return UploadFileUsingPresignedUrl(file)
+	}
+	return UploadLargeFileUsingPresignedUrl(file)
+}
+
 func UploadFileUsingPresignedUrl(tempFile *os.File) (s3Filename string, etag string, err error) {
 	stats, _ := tempFile.Stat()
 	url := GetPresignedUrlUploadFile(infrastructure.GetBucketName(), tempFile.Name())
@@ -103,21 +131,25 @@ func UploadLargeFileUsingPresignedUrl(tempFile *os.File) (s3Filename string, eta
 	if err != nil {
 		return
 	}
-	// multipart upload
+	// multipart upload, each part is streamed from file (file is not loaded to memory)
 	var start, currentSize int
 	var remaining = int(stats.Size())
 	var partNum = 1
 	completedPartChannel := make(chan *PresignedUrlPart)
 	defer close(completedPartChannel)
-	buffer := make([]byte, stats.Size())
-	tempFile.Read(buffer)
+	slots := make(chan struct{}, UPLOAD_PART_CONCURRENCY)
 	for start = 0; remaining != 0; start += PART_SIZE {
 		if remaining < PART_SIZE*2 {
 			currentSize = remaining
 		} else {
 			currentSize = PART_SIZE
 		}
-		go uploadPartFileUsingPresignedUrl(listPresignedUrlPart[partNum-1], buffer[start:start+currentSize], completedPartChannel)
+		part := io.NewSectionReader(tempFile, int64(start), int64(currentSize))
+		go func(presignedPart PresignedUrlPart) {
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			uploadPartFileUsingPresignedUrl(presignedPart, part, completedPartChannel)
+		}(listPresignedUrlPart[partNum-1])
 		// Detract the current part size from remaining
 		remaining -= currentSize
 
@@ -143,7 +175,10 @@ func UploadLargeFileUsingPresignedUrl(tempFile *os.File) (s3Filename string, eta
 	}
 	// Import to redis
 	value, _ := json.Marshal(listInfoPart)
-	client := infrastructure.GetRedisClient()
+	client, err := infrastructure.GetRedisClient()
+	if err != nil {
+		return
+	}
 	ctxTimeout, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
 	defer cancel()
 	err = client.HSet(ctxTimeout, infrastructure.GetBucketName(), tempFile.Name(), string(value)).Err()
@@ -164,15 +199,23 @@ func UploadLargeFileUsingPresignedUrl(tempFile *os.File) (s3Filename string, eta
 	return
 }
 
-func uploadPartFileUsingPresignedUrl(part PresignedUrlPart, fileBytes []byte, completedParts chan *PresignedUrlPart) {
+func uploadPartFileUsingPresignedUrl(part PresignedUrlPart, body *io.SectionReader, completedParts chan *PresignedUrlPart) {
 	var try int
 	for try <= RETRIES {
-		body := bytes.NewReader(fileBytes)
-		req, err1 := http.NewRequest(http.MethodPut, part.Url, body)
 		ctxTimeout, cancel := context.WithTimeout(context.Background(), time.Second*60)
-		defer cancel()
-		resp, err2 := http.DefaultClient.Do(req.WithContext(ctxTimeout))
-		err := utils.FirstNonNil(err1, err2)
+		req, err := http.NewRequestWithContext(ctxTimeout, http.MethodPut, part.Url, io.NewSectionReader(body, 0, body.Size()))
+		var resp *http.Response
+		if err == nil {
+			req.ContentLength = body.Size()
+			resp, err = http.DefaultClient.Do(req)
+		}
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				err = fmt.Errorf("upload part %d status %d", part.PartNumber, resp.StatusCode)
+			}
+		}
+		cancel()
 		// Upload failed
 		if err != nil {
 			fmt.Println(err)
@@ -180,7 +223,6 @@ func uploadPartFileUsingPresignedUrl(part PresignedUrlPart, fileBytes []byte, co
 			if try == RETRIES {
 				completedParts <- &PresignedUrlPart{
 					UploadId:   part.UploadId,
-					ETag:       resp.Header.Get("Etag"),
 					PartNumber: part.PartNumber,
 					Url:        part.Url,
 					Success:    false,
@@ -343,7 +385,10 @@ func uploadNormalFile(svc *s3.S3, tempFile *os.File) (s3Filename string, etag st
 }
 
 func DownloadFileFromBucket(filename string, localFilePath string) error {
-	svc := s3.New(infrastructure.GetAwsSession())
+	svc, err := newS3()
+	if err != nil {
+		return err
+	}
 
 	ctxTimeoutHeader, cancel := context.WithTimeout(context.Background(), time.Second*10)
 	defer cancel()
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"crawlweb/model"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BatchConfig Workers is number of urls crawled at same time, Timeout is limit of each url
+type BatchConfig struct {
+	Workers int
+	Timeout time.Duration
+}
+
+var DefaultBatchConfig = BatchConfig{
+	Workers: 8,
+	Timeout: 2 * time.Minute,
+}
+
+type BatchSummary struct {
+	Total     int
+	Succeeded int
+	Failed    int
+	Duration  time.Duration
+}
+
+func (summary BatchSummary) String() string {
+	return fmt.Sprintf("total: %d, succeeded: %d, failed: %d, duration: %s",
+		summary.Total, summary.Succeeded, summary.Failed, summary.Duration.Round(time.Millisecond))
+}
+
+type batchJob struct {
+	line int
+	url  string
+}
+
+// RunBatch crawl urls read from r (one url per line, first column of csv), write one record per url to w then close w.
+// Records are written in order of completion, error of url is written in its record
+func RunBatch(ctx context.Context, r io.Reader, w RecordWriter, config BatchConfig, options CrawlOptions) (summary BatchSummary, err error) {
+	start := time.Now()
+	workers := config.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	jobs := make(chan batchJob)
+	records := make(chan model.BatchRecord)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				records <- crawlBatchJob(ctx, job, config.Timeout, options)
+			}
+		}()
+	}
+
+	// read input
+	readErr := make(chan error, 1)
+	go func() {
+		defer close(jobs)
+		scanner := bufio.NewScanner(r)
+		line := 0
+		for scanner.Scan() {
+			line++
+			url := parseBatchLine(scanner.Text())
+			if url == "" {
+				continue
+			}
+			select {
+			case jobs <- batchJob{line: line, url: url}:
+			case <-ctx.Done():
+				readErr <- ctx.Err()
+				return
+			}
+		}
+		readErr <- scanner.Err()
+	}()
+
+	go func() {
+		wg.Wait()
+		close(records)
+	}()
+
+	for record := range records {
+		summary.Total++
+		if record.Error != "" {
+			summary.Failed++
+		} else {
+			summary.Succeeded++
+		}
+		if errWrite := w.Write(record); errWrite != nil && err == nil {
+			log.Println("write batch record error:", errWrite)
+			err = errWrite
+		}
+	}
+	if errClose := w.Close(); errClose != nil && err == nil {
+		err = errClose
+	}
+	if errRead := <-readErr; errRead != nil && err == nil {
+		err = errRead
+	}
+	summary.Duration = time.Since(start)
+	return summary, err
+}
+
+func crawlBatchJob(ctx context.Context, job batchJob, timeout time.Duration, options CrawlOptions) (record model.BatchRecord) {
+	start := time.Now()
+	record = model.BatchRecord{Line: job.line, Url: job.url}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	openGraphModel, err := Crawl(ctx, job.url, options)
+	record.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		log.Println("crawl error:", job.url, err)
+		record.Error = err.Error()
+		return
+	}
+	record.Result = &openGraphModel
+	return
+}
+
+// parseBatchLine url of line (first column of csv or tsv), empty for blank line, comment (#) and header
+func parseBatchLine(line string) string {
+	line = strings.TrimSpace(strings.TrimPrefix(line, "\ufeff"))
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ""
+	}
+	reader := csv.NewReader(strings.NewReader(line))
+	reader.LazyQuotes = true
+	if strings.Contains(line, "\t") {
+		reader.Comma = '\t'
+	}
+	fields, err := reader.Read()
+	if err == nil && len(fields) > 0 {
+		line = strings.TrimSpace(fields[0])
+	}
+	if !strings.Contains(line, "://") {
+		return ""
+	}
+	return line
+}
@@ -0,0 +1,252 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"crawlweb/model"
+	"crawlweb/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+)
+
+var (
+	CheckpointDir = "./storage/checkpoints"
+	// CheckpointInterval state of job is saved at most once in this interval, and when job stops
+	CheckpointInterval = 30 * time.Second
+)
+
+var (
+	ErrCheckpointNotFound = errors.New("checkpoint of crawl job not found")
+	ErrCrawlJobDone       = errors.New("crawl job is already done")
+	validJobName          = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+)
+
+// CrawlCheckpoint saved state of named site crawl job
+type CrawlCheckpoint struct {
+	Job    string
+	Config SiteCrawlConfig
+	// Options crawl options of job, resumed job uploads to same storage
+	Options CrawlOptions
+	// Output path of JSON Lines output, resumed job appends to it
+	Output string
+	State  *CrawlState
+	// Emitted canonical urls whose record is written to output, they are not written again after resume.
+	// Urls emitted after last save are read from emitted journal
+	Emitted     map[string]bool
+	Done        bool
+	UpdatedTime int64
+}
+
+// jobUploadCache uploaded files of job, every upload is appended to journal file immediately
+// so it is not uploaded again after crash
+type jobUploadCache struct {
+	mutex   sync.Mutex
+	uploads map[string]UploadedFile
+	journal *os.File
+}
+
+type uploadJournalEntry struct {
+	Key  string
+	File UploadedFile
+}
+
+// CrawlSiteJob start named site crawl job writing to output, its state is saved to checkpoint for ResumeCrawlJob
+func CrawlSiteJob(ctx context.Context, job string, config SiteCrawlConfig, options CrawlOptions, output string, emit func(model.CrawlRecord)) error {
+	if !validJobName.MatchString(job) {
+		return fmt.Errorf("invalid job name: %q", job)
+	}
+	// new job replaces old job with same name
+	os.Remove(uploadJournalPath(job))
+	os.Remove(emittedJournalPath(job))
+	options.UploadCache = nil
+	checkpoint := &CrawlCheckpoint{Job: job, Config: config, Options: options, Output: output, State: newCrawlState(config.Seeds), Emitted: map[string]bool{}}
+	return runCrawlJob(ctx, checkpoint, emit)
+}
+
+// ResumeCrawlJob continue named site crawl job from its last checkpoint with options of job.
+// Pages crawled after last checkpoint are crawled again for their links, their records are not emitted again
+func ResumeCrawlJob(ctx context.Context, job string, emit func(model.CrawlRecord)) error {
+	checkpoint, err := LoadCheckpoint(job)
+	if err != nil {
+		return err
+	}
+	if checkpoint.Done {
+		return ErrCrawlJobDone
+	}
+	log.Println("resume crawl job:", job, "crawled pages:", checkpoint.State.Pages, "queued:", len(checkpoint.State.Queue), "emitted:", len(checkpoint.Emitted))
+	return runCrawlJob(ctx, checkpoint, emit)
+}
+
+func runCrawlJob(ctx context.Context, checkpoint *CrawlCheckpoint, emit func(model.CrawlRecord)) error {
+	uploadCache, err := openUploadCache(checkpoint.Job)
+	if err != nil {
+		return err
+	}
+	defer uploadCache.close()
+	options := checkpoint.Options
+	options.UploadCache = uploadCache
+
+	emittedJournal, err := os.OpenFile(emittedJournalPath(checkpoint.Job), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer emittedJournal.Close()
+	emitOnce := func(record model.CrawlRecord) {
+		key := utils.CanonicalUrlOrRaw(record.Url)
+		if checkpoint.Emitted[key] {
+			return
+		}
+		emit(record)
+		checkpoint.Emitted[key] = true
+		if _, err := emittedJournal.WriteString(key + "\n"); err != nil {
+			log.Println("write emitted journal error:", err)
+		}
+	}
+
+	lastSave := time.Now()
+	err = crawlSite(ctx, checkpoint.Config, options, checkpoint.State, emitOnce, func(state *CrawlState) {
+		if time.Since(lastSave) < CheckpointInterval {
+			return
+		}
+		lastSave = time.Now()
+		if errSave := SaveCheckpoint(checkpoint); errSave != nil {
+			log.Println("save checkpoint error:", errSave)
+		}
+	})
+	// job is stopped by error or signal, save progress for resuming
+	checkpoint.Done = err == nil
+	if errSave := SaveCheckpoint(checkpoint); errSave != nil {
+		log.Println("save checkpoint error:", errSave)
+		if err == nil {
+			err = errSave
+		}
+	}
+	return err
+}
+
+// SaveCheckpoint write checkpoint to temp file then rename it, old checkpoint is kept when writing fail
+func SaveCheckpoint(checkpoint *CrawlCheckpoint) error {
+	checkpoint.UpdatedTime = time.Now().Unix()
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(CheckpointDir, 0755); err != nil {
+		return err
+	}
+	filePath := checkpointPath(checkpoint.Job)
+	tmpPath := filePath + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, filePath)
+}
+
+func LoadCheckpoint(job string) (checkpoint *CrawlCheckpoint, err error) {
+	if !validJobName.MatchString(job) {
+		return nil, fmt.Errorf("invalid job name: %q", job)
+	}
+	data, err := ioutil.ReadFile(checkpointPath(job))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s", ErrCheckpointNotFound, job)
+	}
+	if err != nil {
+		return nil, err
+	}
+	checkpoint = &CrawlCheckpoint{}
+	if err = json.Unmarshal(data, checkpoint); err != nil {
+		return nil, err
+	}
+	if checkpoint.State == nil {
+		checkpoint.State = newCrawlState(checkpoint.Config.Seeds)
+	}
+	if checkpoint.State.Seen == nil {
+		checkpoint.State.Seen = map[string]bool{}
+	}
+	if checkpoint.State.HostPages == nil {
+		checkpoint.State.HostPages = map[string]int{}
+	}
+	if checkpoint.Emitted == nil {
+		checkpoint.Emitted = map[string]bool{}
+	}
+	if file, err := os.Open(emittedJournalPath(job)); err == nil {
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(make([]byte, 64*1024), 1<<20)
+		for scanner.Scan() {
+			checkpoint.Emitted[scanner.Text()] = true
+		}
+		file.Close()
+	}
+	return checkpoint, nil
+}
+
+func checkpointPath(job string) string {
+	return filepath.Join(CheckpointDir, job+".json")
+}
+
+func emittedJournalPath(job string) string {
+	return filepath.Join(CheckpointDir, job+".emitted")
+}
+
+func uploadJournalPath(job string) string {
+	return filepath.Join(CheckpointDir, job+".uploads.jsonl")
+}
+
+// openUploadCache load uploads of job from journal file
+func openUploadCache(job string) (*jobUploadCache, error) {
+	if err := os.MkdirAll(CheckpointDir, 0755); err != nil {
+		return nil, err
+	}
+	cache := &jobUploadCache{uploads: map[string]UploadedFile{}}
+	filePath := uploadJournalPath(job)
+	if file, err := os.Open(filePath); err == nil {
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(make([]byte, 64*1024), 1<<20)
+		for scanner.Scan() {
+			entry := uploadJournalEntry{}
+			// last line can be broken when process is killed
+			if err := json.Unmarshal(scanner.Bytes(), &entry); err == nil {
+				cache.uploads[entry.Key] = entry.File
+			}
+		}
+		file.Close()
+	}
+	journal, err := os.OpenFile(filePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	cache.journal = journal
+	return cache, nil
+}
+
+func (cache *jobUploadCache) GetUpload(key string) (UploadedFile, bool) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	file, ok := cache.uploads[key]
+	return file, ok
+}
+
+func (cache *jobUploadCache) PutUpload(key string, file UploadedFile) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	cache.uploads[key] = file
+	data, err := json.Marshal(uploadJournalEntry{Key: key, File: file})
+	if err != nil {
+		return
+	}
+	if _, err := cache.journal.Write(append(data, '\n')); err != nil {
+		log.Println("write upload journal error:", err)
+	}
+}
+
+func (cache *jobUploadCache) close() {
+	cache.journal.Close()
+}
@@ -0,0 +1,352 @@
+package service
+
+import (
+	"context"
+	"crawlweb/model"
+	"crawlweb/utils"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const (
+	STORAGE_S3    = "s3"
+	STORAGE_DRIVE = "drive"
+	STORAGE_NONE  = "none"
+)
+
+// ErrStatusCode page response is not 200
+var ErrStatusCode = errors.New("status code error")
+
+// CrawlOptions options of extraction pipeline
+type CrawlOptions struct {
+	SkipUpload bool
+	// Storage where image and preview card are uploaded: s3 (default), drive or none
+	Storage string
+	// CollectLinks keep links of html page in OpenGraphModel.Links
+	CollectLinks bool
+	// FollowPagination stitch content of next/previous pages of article
+	FollowPagination bool
+	// NoCache always fetch page from network, page cache and cached result are not used nor updated
+	NoCache bool
+	// UploadCache skip uploading image and preview card which are uploaded before, nil is no cache
+	UploadCache UploadCache `json:"-"`
+}
+
+// UploadCache uploaded files of crawl job by key (image url or preview card of page url)
+type UploadCache interface {
+	GetUpload(key string) (UploadedFile, bool)
+	PutUpload(key string, file UploadedFile)
+}
+
+type UploadedFile struct {
+	Filename string
+	Etag     string
+	model.ImageColorInfo
+}
+
+// Crawl fetch url, parse open graph info then upload image and preview card
+func Crawl(ctx context.Context, url string, options CrawlOptions) (openGraphModel model.OpenGraphModel, err error) {
+	// Crawl website using shared fetcher and goquery
+	var res *http.Response
+	var redirectChain []model.RedirectHop
+	var attempts int
+	var cacheEntry *PageCacheEntry
+	if options.NoCache {
+		res, redirectChain, attempts, err = FetchPage(ctx, url)
+	} else {
+		res, redirectChain, attempts, cacheEntry, err = FetchPageCached(ctx, url)
+	}
+	if err != nil {
+		return
+	}
+	defer res.Body.Close()
+	if res.StatusCode != 200 {
+		err = fmt.Errorf("%w: %d %s", ErrStatusCode, res.StatusCode, res.Status)
+		return
+	}
+	// page is not modified, reuse last result of same options (links are not cached)
+	if result := cacheEntry.result(options); result != nil && !options.CollectLinks {
+		openGraphModel = *result
+		openGraphModel.FetchAttempts = attempts
+		return
+	}
+
+	openGraphModel, err = ProcessResponse(ctx, url, res, options)
+	if err != nil {
+		return
+	}
+	openGraphModel.RedirectChain = redirectChain
+	openGraphModel.FetchAttempts = attempts
+	if options.NoCache {
+		return openGraphModel, nil
+	}
+
+	err = SavePageCacheResult(ctx, url, options, openGraphModel)
+	if err != nil {
+		log.Println("save page cache error:", err)
+	}
+	return openGraphModel, nil
+}
+
+// CrawlOffline run extraction pipeline with response loaded from local file, stdin or WARC file.
+// Offline crawl does not use network: image and preview card are not uploaded, pagination is not followed
+func CrawlOffline(ctx context.Context, url string, options CrawlOptions, load func() (*http.Response, error)) (openGraphModel model.OpenGraphModel, err error) {
+	options.SkipUpload = true
+	options.FollowPagination = false
+	res, err := load()
+	if err != nil {
+		return
+	}
+	defer res.Body.Close()
+	if url == "" {
+		url = res.Request.URL.String()
+	}
+	return ProcessResponse(ctx, url, res, options)
+}
+
+// ProcessResponse parse open graph info from response then upload image and preview card
+func ProcessResponse(ctx context.Context, url string, res *http.Response, options CrawlOptions) (openGraphModel model.OpenGraphModel, err error) {
+	if IsHtmlContentType(res.Header.Get("content-type")) {
+		// Load the HTML document
+		doc, errDoc := goquery.NewDocumentFromReader(res.Body)
+		if errDoc != nil {
+			err = errDoc
+			return
+		}
+		content, pages := ExtractArticleContent(doc), 1
+		metaDoc, metaUrl := doc, res.Request.URL
+		if options.FollowPagination {
+			// crawled page may be page 2 or later, metadata is taken from first page
+			content, pages, metaDoc, metaUrl = stitchPages(ctx, doc, res.Request.URL, content)
+		}
+		openGraphModel = ParseDoc(metaDoc)
+		// resolve relative links with url of page
+		openGraphModel.Image = resolveUrl(metaUrl, openGraphModel.Image)
+		openGraphModel.Favicon = resolveUrl(metaUrl, openGraphModel.Favicon)
+		// hash of crawled page, watch detects changes of this page
+		openGraphModel.ContentHash = contentHash(doc)
+		if options.CollectLinks {
+			openGraphModel.Links = ExtractLinks(doc, res.Request.URL)
+		}
+		openGraphModel.Content = content
+		openGraphModel.ContentPages = pages
+	} else {
+		// direct image, pdf, media file...
+		openGraphModel, err = ParseNonHtml(url, res)
+		if err != nil {
+			log.Println("parse file error:", err)
+		}
+	}
+	openGraphModel.FinalUrl = res.Request.URL.String()
+	openGraphModel.CanonicalUrl = utils.CanonicalUrlOrRaw(openGraphModel.FinalUrl)
+
+	if options.SkipUpload || options.Storage == STORAGE_NONE {
+		return openGraphModel, nil
+	}
+	if options.Storage == STORAGE_DRIVE {
+		uploadToDrive(&openGraphModel, options)
+		return openGraphModel, nil
+	}
+	imageKey := "image:" + utils.CanonicalUrlOrRaw(openGraphModel.Image)
+	if uploaded, ok := getUpload(options, imageKey); ok && openGraphModel.Image != "" {
+		openGraphModel.Filename, openGraphModel.Etag, openGraphModel.ImageColorInfo = uploaded.Filename, uploaded.Etag, uploaded.ImageColorInfo
+	} else {
+		openGraphModel.Filename, openGraphModel.Etag, openGraphModel.ImageColorInfo, openGraphModel.ImageFetchAttempts, err = UploadFileToBucket(openGraphModel.Image, res.Header.Get("content-type"))
+		if err != nil {
+			log.Println("get error:", err)
+		} else if options.UploadCache != nil && openGraphModel.Filename != "" {
+			options.UploadCache.PutUpload(imageKey, UploadedFile{Filename: openGraphModel.Filename, Etag: openGraphModel.Etag, ImageColorInfo: openGraphModel.ImageColorInfo})
+		}
+	}
+	cardKey := "card:" + openGraphModel.CanonicalUrl
+	if uploaded, ok := getUpload(options, cardKey); ok {
+		openGraphModel.PreviewCard = uploaded.Filename
+	} else {
+		openGraphModel.PreviewCard, _, err = RenderAndUploadPreviewCard(openGraphModel, CARD_TEMPLATE_DEFAULT)
+		if err != nil {
+			log.Println("render preview card error:", err)
+		} else if options.UploadCache != nil && openGraphModel.PreviewCard != "" {
+			options.UploadCache.PutUpload(cardKey, UploadedFile{Filename: openGraphModel.PreviewCard})
+		}
+	}
+	return openGraphModel, nil
+}
+
+// uploadToDrive upload image and preview card to drive, Filename and PreviewCard are drive file ids
+func uploadToDrive(openGraphModel *model.OpenGraphModel, options CrawlOptions) {
+	imageKey := "drive:image:" + utils.CanonicalUrlOrRaw(openGraphModel.Image)
+	if uploaded, ok := getUpload(options, imageKey); ok && openGraphModel.Image != "" {
+		openGraphModel.Filename = uploaded.Filename
+	} else {
+		openGraphModel.Filename = CreateFileAndSave(openGraphModel.Image)
+		if options.UploadCache != nil && openGraphModel.Filename != "" {
+			options.UploadCache.PutUpload(imageKey, UploadedFile{Filename: openGraphModel.Filename})
+		}
+	}
+	cardKey := "drive:card:" + openGraphModel.CanonicalUrl
+	if uploaded, ok := getUpload(options, cardKey); ok {
+		openGraphModel.PreviewCard = uploaded.Filename
+		return
+	}
+	filePath, err := RenderPreviewCard(*openGraphModel, CARD_TEMPLATE_DEFAULT)
+	if err != nil {
+		log.Println("render preview card error:", err)
+		return
+	}
+	defer os.Remove(filePath)
+	openGraphModel.PreviewCard, err = UploadLocalFileToDrive(filePath, "image/png")
+	if err != nil {
+		log.Println("upload preview card error:", err)
+	} else if options.UploadCache != nil && openGraphModel.PreviewCard != "" {
+		options.UploadCache.PutUpload(cardKey, UploadedFile{Filename: openGraphModel.PreviewCard})
+	}
+}
+
+func getUpload(options CrawlOptions, key string) (UploadedFile, bool) {
+	if options.UploadCache == nil {
+		return UploadedFile{}, false
+	}
+	return options.UploadCache.GetUpload(key)
+}
+
+func resolveUrl(base *neturl.URL, ref string) string {
+	if ref == "" {
+		return ref
+	}
+	u, err := base.Parse(strings.TrimSpace(ref))
+	if err != nil {
+		return ref
+	}
+	return u.String()
+}
+
+// ExtractLinks absolute http(s) links of a[href] in page, without fragment
+func ExtractLinks(doc *goquery.Document, base *neturl.URL) (links []string) {
+	seen := map[string]bool{}
+	doc.Find("a[href]").Each(func(i int, el *goquery.Selection) {
+		href, _ := el.Attr("href")
+		u, err := base.Parse(strings.TrimSpace(href))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return
+		}
+		u.Fragment = ""
+		link := u.String()
+		if !seen[link] {
+			seen[link] = true
+			links = append(links, link)
+		}
+	})
+	return
+}
+
+func ParseDoc(doc *goquery.Document) (openGraphModel model.OpenGraphModel) {
+	metaAttr := findMetaAttr(doc)
+	doc.Find("meta").Each(func(i int, el *goquery.Selection) {
+		// type
+		value, _ := el.Attr(metaAttr)
+		if strings.Contains(value, "type") {
+			openGraphModel.Type, _ = el.Attr("content")
+		}
+		// Title
+		if strings.Contains(value, "title") {
+			openGraphModel.Title, _ = el.Attr("content")
+		}
+		// siteName
+		if metaAttr == "name" {
+			if strings.Contains(value, "site") {
+				openGraphModel.SiteName, _ = el.Attr("content")
+			}
+		} else if metaAttr == "property" {
+			if strings.EqualFold(value, "og:site_name") {
+				openGraphModel.SiteName, _ = el.Attr("content")
+			}
+		}
+		// description
+		if strings.Contains(value, "description") {
+			openGraphModel.Description, _ = el.Attr("content")
+		}
+		// author
+		if strings.Contains(value, "author") {
+			openGraphModel.Author, _ = el.Attr("content")
+		}
+		// image
+		if strings.Contains(value, "image") && !strings.Contains(value, "image:") {
+			openGraphModel.Image, _ = el.Attr("content")
+		}
+		// price
+		if strings.HasSuffix(value, "price:amount") {
+			openGraphModel.Price, _ = el.Attr("content")
+		}
+		if strings.HasSuffix(value, "price:currency") {
+			openGraphModel.Currency, _ = el.Attr("content")
+		}
+		// url
+		if strings.Contains(value, "url") {
+			openGraphModel.Url, _ = el.Attr("content")
+		}
+	})
+	if openGraphModel.Title == "" {
+		openGraphModel.Title = doc.Find("title").Text()
+	}
+	// price of schema.org microdata
+	if openGraphModel.Price == "" {
+		el := doc.Find("[itemprop='price']").First()
+		openGraphModel.Price = strings.TrimSpace(el.AttrOr("content", el.Text()))
+		el = doc.Find("[itemprop='priceCurrency']").First()
+		openGraphModel.Currency = strings.TrimSpace(el.AttrOr("content", el.Text()))
+	}
+	openGraphModel.ContentHash = contentHash(doc)
+	// favicon
+	doc.Find("link[rel~='icon']").EachWithBreak(func(i int, el *goquery.Selection) bool {
+		openGraphModel.Favicon, _ = el.Attr("href")
+		return openGraphModel.Favicon == ""
+	})
+	return
+}
+
+// contentHash sha256 of text of body with collapsed spaces, script and style are ignored
+func contentHash(doc *goquery.Document) string {
+	body := doc.Find("body").Clone()
+	body.Find("script, style, noscript").Remove()
+	text := strings.Join(strings.Fields(body.Text()), " ")
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+func findMetaAttr(doc *goquery.Document) (metaAttr string) {
+	// property
+	doc.Find("meta").Each(func(i int, el *goquery.Selection) {
+		value, exists := el.Attr("property")
+		if exists {
+			if strings.Contains(value, "og:") {
+				metaAttr = "property"
+				return
+			}
+		}
+	})
+	if metaAttr != "" {
+		return
+	}
+	// // name
+	// doc.Find("meta").Each(func(i int, el *goquery.Selection) {
+	// 	value, exists := el.Attr("name")
+	// 	if exists {
+	// 		log.Println(i)
+	// 		log.Println("name:", value)
+	// 		if strings.Contains(value, "og:")  {
+	// 			metaAttr = "name"
+	// 			return
+	// 		}
+	// 	}
+	// })
+	return "name"
+}
@@ -1,42 +1,65 @@
 package service
 
 import (
+	"context"
 	"crawlweb/infrastructure"
 	"errors"
 	"io"
 	"log"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/lithammer/shortuuid"
 )
 
 func DownloadFile(URL, fileName string) error {
+	_, err := DownloadFileWithRetry(URL, fileName)
+	return err
+}
+
+// DownloadFileWithRetry download file with retry policy of fetcher, return number of attempts
+func DownloadFileWithRetry(URL, fileName string) (attempts int, err error) {
+	return downloadWithRetry(URL, fileName, Fetch)
+}
+
+// DownloadImage download image, content type must be allowed image content type of fetcher config
+func DownloadImage(URL, fileName string) error {
+	_, err := DownloadImageWithRetry(URL, fileName)
+	return err
+}
+
+// DownloadImageWithRetry same as DownloadFileWithRetry for image
+func DownloadImageWithRetry(URL, fileName string) (attempts int, err error) {
+	return downloadWithRetry(URL, fileName, FetchImage)
+}
+
+func downloadWithRetry(URL, fileName string, fetch func(ctx context.Context, url string) (*http.Response, error)) (attempts int, err error) {
+	ctx := context.Background()
 	//Get the response bytes from the url
-	response, err := http.Get(URL)
+	response, attempts, err := FetchWithRetry(ctx, func() (*http.Response, error) {
+		return fetch(ctx, URL)
+	})
 	if err != nil {
-		return err
+		return
 	}
 	defer response.Body.Close()
 
 	if response.StatusCode != 200 {
-		return errors.New("Received non 200 response code")
+		err = errors.New("Received non 200 response code")
+		return
 	}
 	//Create a empty file
 	file, err := os.Create(fileName)
 	if err != nil {
-		return err
+		return
 	}
 	defer file.Close()
 
 	//Write the bytes to the fiel
 	_, err = io.Copy(file, response.Body)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return
 }
 
 func CreateFileAndSave(url string) (driveId string) {
@@ -56,7 +79,12 @@ func CreateFileAndSave(url string) (driveId string) {
 		return
 	}
 	defer tempFile.Close()
-	tmp, err := CreateFile(infrastructure.GetDriveService(), fileName, infrastructure.MIME_File, tempFile, infrastructure.GetRootFolderDrive())
+	driveService, err := infrastructure.GetDriveService()
+	if err != nil {
+		log.Println("error when create file to drive:", err)
+		return
+	}
+	tmp, err := CreateFile(driveService, fileName, infrastructure.MIME_File, tempFile, infrastructure.GetRootFolderDrive())
 	if err != nil {
 		log.Println("error when create file to drive:", err)
 		return
@@ -65,6 +93,28 @@ func CreateFileAndSave(url string) (driveId string) {
 	return
 }
 
+// UploadLocalFileToDrive upload local file to root folder of drive
+func UploadLocalFileToDrive(filePath string, mimeType string) (driveId string, err error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	if mimeType == "" {
+		mimeType = infrastructure.MIME_File
+	}
+	driveService, err := infrastructure.GetDriveService()
+	if err != nil {
+		return
+	}
+	driveFile, err := CreateFile(driveService, filepath.Base(filePath), mimeType, file, infrastructure.GetRootFolderDrive())
+	if err != nil {
+		log.Println("error when create file to drive:", err)
+		return
+	}
+	return driveFile.Id, nil
+}
+
 func GenCode() string {
 	id := shortuuid.New()
 	return strings.ToUpper(id[0:10])
@@ -1,6 +1,8 @@
 package service
 
 import (
+	"context"
+	"crawlweb/infrastructure"
 	"io"
 	"log"
 	"net/http"
@@ -67,13 +69,12 @@ func DownloadFileFromDrive(fileId, fileName string) error {
 	}
 	defer output.Close()
 
-	c := http.Client{
-		CheckRedirect: func(r *http.Request, via []*http.Request) error {
-			r.URL.Opaque = r.URL.Path
-			return nil
-		},
+	c := *infrastructure.GetHttpClient()
+	c.CheckRedirect = func(r *http.Request, via []*http.Request) error {
+		r.URL.Opaque = r.URL.Path
+		return infrastructure.CheckRedirect(r, via)
 	}
-	response, err := c.Get(DownloadURL + fileId)
+	response, err := FetchWithClient(context.Background(), &c, DownloadURL+fileId)
 	if err != nil {
 		log.Println("Error while downloading: ", err)
 		return err
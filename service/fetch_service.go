@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"crawlweb/infrastructure"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+var (
+	ErrContentTypeNotAllowed = errors.New("content type is not allowed")
+	ErrBodyTooLarge          = errors.New("response body is too large")
+)
+
+// Fetch GET url using shared http client, headers and limits of fetcher config.
+// Content type is not checked (downloaded file can be any type)
+func Fetch(ctx context.Context, url string) (*http.Response, error) {
+	return FetchWithClient(ctx, infrastructure.GetHttpClient(), url)
+}
+
+// FetchImage same as Fetch, content type must be allowed image content type of fetcher config
+func FetchImage(ctx context.Context, url string) (*http.Response, error) {
+	return FetchWithHeader(ctx, infrastructure.GetHttpClient(), url, nil, infrastructure.GetFetcherConfig().AllowedImageContentTypes)
+}
+
+// FetchWithClient same as Fetch but using custom client (ex: different redirect policy)
+func FetchWithClient(ctx context.Context, client *http.Client, url string) (*http.Response, error) {
+	return FetchWithHeader(ctx, client, url, nil, nil)
+}
+
+// FetchWithHeader same as FetchWithClient, header is added after default headers of fetcher config.
+// Empty allowedContentTypes is allow all
+func FetchWithHeader(ctx context.Context, client *http.Client, url string, header http.Header, allowedContentTypes []string) (*http.Response, error) {
+	return fetch(ctx, client, url, header, allowedContentTypes, true)
+}
+
+// fetch GET url with url policy, headers and session of fetcher config, waiting rate limit of host when waitRateLimit.
+// robots.txt is fetched without waiting, crawl delay of host is read from it by rate limit
+func fetch(ctx context.Context, client *http.Client, url string, header http.Header, allowedContentTypes []string, waitRateLimit bool) (*http.Response, error) {
+	config := infrastructure.GetFetcherConfig()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", config.UserAgent)
+	req.Header.Set("Accept-Language", config.AcceptLanguage)
+	for key, value := range config.Headers {
+		req.Header.Set(key, value)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	err = infrastructure.CheckUrl(ctx, req.URL)
+	if err != nil {
+		return nil, err
+	}
+	err = applySession(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if waitRateLimit {
+		err = WaitRateLimit(ctx, req.URL)
+		if err != nil {
+			return nil, err
+		}
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode == http.StatusNotModified {
+		return res, nil
+	}
+	if !isAllowedContentType(res.Header.Get("content-type"), allowedContentTypes) {
+		res.Body.Close()
+		return nil, fmt.Errorf("%w: %s", ErrContentTypeNotAllowed, res.Header.Get("content-type"))
+	}
+	if config.MaxBodySize > 0 {
+		if res.ContentLength > config.MaxBodySize {
+			res.Body.Close()
+			return nil, fmt.Errorf("%w: %d bytes", ErrBodyTooLarge, res.ContentLength)
+		}
+		res.Body = &limitedBody{ReadCloser: res.Body, remaining: config.MaxBodySize}
+	}
+	return res, nil
+}
+
+func isAllowedContentType(contentType string, allowedContentTypes []string) bool {
+	if len(allowedContentTypes) == 0 {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.ToLower(strings.TrimSpace(contentType))
+	}
+	for _, allowed := range allowedContentTypes {
+		allowed = strings.ToLower(allowed)
+		if allowed == mediaType || allowed == "*/*" {
+			return true
+		}
+		if strings.HasSuffix(allowed, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(allowed, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// limitedBody return ErrBodyTooLarge when reading more than max body size
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+}
+
+func (l *limitedBody) Read(p []byte) (int, error) {
+	if l.remaining < 0 {
+		return 0, ErrBodyTooLarge
+	}
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.ReadCloser.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return n + int(l.remaining), ErrBodyTooLarge
+	}
+	return n, err
+}
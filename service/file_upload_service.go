@@ -6,11 +6,17 @@ import (
 	"crawlweb/model"
 	"crawlweb/utils"
 	"log"
+	"mime"
+	"path/filepath"
 	"time"
 )
 
 func Insert(info model.FileUploadInfo) error {
-	db := infrastructure.GetDB()
+	db, err := infrastructure.GetDB()
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
 
 	now := time.Now()
 	info.CreatedAt = &now
@@ -21,10 +27,59 @@ func Insert(info model.FileUploadInfo) error {
 
 	ctxTimeout, cancel := context.WithTimeout(context.Background(), time.Second*20)
 	defer cancel()
-	_, err := db.NamedExecContext(ctxTimeout, `INSERT INTO file_upload_infos (file_size, file_name, ext, mime_type, created_time, updated_time, created_at, updated_at) 
-		VALUES (:file_size, :file_name, :ext, :mime_type, :created_time, :updated_time, :created_at, :updated_at)`, &info)
+	_, err = db.NamedExecContext(ctxTimeout, `INSERT INTO file_upload_infos (file_size, file_name, ext, mime_type, dominant_color, palette, blur_hash, created_time, updated_time, created_at, updated_at) 
+		VALUES (:file_size, :file_name, :ext, :mime_type, :dominant_color, :palette, :blur_hash, :created_time, :updated_time, :created_at, :updated_at)`, &info)
 	if err != nil {
 		log.Println(err)
 	}
 	return nil
 }
+
+// fileUploadInfoColumns columns of file_upload_infos, nullable columns are empty string
+const fileUploadInfoColumns = `id, file_id, IFNULL(file_size, 0) AS file_size, IFNULL(file_name, '') AS file_name, IFNULL(ext, '') AS ext,
+	IFNULL(mime_type, '') AS mime_type, IFNULL(dominant_color, '') AS dominant_color, IFNULL(palette, '') AS palette,
+	IFNULL(blur_hash, '') AS blur_hash, created_time, updated_time`
+
+// ListFileUploadInfos latest uploaded files
+func ListFileUploadInfos(ctx context.Context, limit int) (infos []model.FileUploadInfo, err error) {
+	db, err := infrastructure.GetDB()
+	if err != nil {
+		return
+	}
+	err = db.SelectContext(ctx, &infos, `SELECT `+fileUploadInfoColumns+` FROM file_upload_infos ORDER BY id DESC LIMIT ?`, limit)
+	return
+}
+
+// GetFileUploadInfo info of uploaded file by file name, sql.ErrNoRows when file is not found
+func GetFileUploadInfo(ctx context.Context, fileName string) (info model.FileUploadInfo, err error) {
+	db, err := infrastructure.GetDB()
+	if err != nil {
+		return
+	}
+	err = db.GetContext(ctx, &info, `SELECT `+fileUploadInfoColumns+` FROM file_upload_infos WHERE file_name = ? ORDER BY id DESC LIMIT 1`, fileName)
+	return
+}
+
+// RecordBucketUpload insert info of file uploaded to bucket by client (presigned url), key is file name of info
+func RecordBucketUpload(ctx context.Context, key string) (info model.FileUploadInfo, err error) {
+	size, contentType, err := GetObjectInfo(infrastructure.GetBucketName(), key)
+	if err != nil {
+		return
+	}
+	ext := filepath.Ext(key)
+	if contentType == "" || contentType == "binary/octet-stream" {
+		if byExt := mime.TypeByExtension(ext); byExt != "" {
+			contentType = byExt
+		}
+	}
+	err = Insert(model.FileUploadInfo{
+		FileSize: size,
+		FileName: key,
+		Ext:      ext,
+		MimeType: contentType,
+	})
+	if err != nil {
+		return
+	}
+	return GetFileUploadInfo(ctx, key)
+}
@@ -0,0 +1,385 @@
+package service
+
+import (
+	"context"
+	"crawlweb/infrastructure"
+	"crawlweb/model"
+	"crawlweb/utils"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/json"
+	"log"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	FRONTIER_PREFIX = "frontier:"
+	// bitmap of redis is limited to 512MB
+	BLOOM_MAX_BITS = 1 << 32
+)
+
+// FrontierConfig url frontier shared between crawler processes through redis
+type FrontierConfig struct {
+	// Name frontier of a crawl job, workers with same name cooperate
+	Name string
+	// HostDelay min delay between 2 urls of same host given to workers
+	HostDelay time.Duration
+	// LeaseTimeout url is given to other worker when it is not acked in this time
+	LeaseTimeout time.Duration
+	// MaxAttempts url is dropped after it is leased this many times
+	MaxAttempts int
+	// ExpectedItems, FalsePositiveRate size of bloom filter of seen urls
+	ExpectedItems     int64
+	FalsePositiveRate float64
+}
+
+var DefaultFrontierConfig = FrontierConfig{
+	Name:              "default",
+	HostDelay:         500 * time.Millisecond,
+	LeaseTimeout:      5 * time.Minute,
+	MaxAttempts:       3,
+	ExpectedItems:     10000000,
+	FalsePositiveRate: 0.001,
+}
+
+// FrontierItem url in frontier, item with higher priority is leased first
+type FrontierItem struct {
+	Url      string `json:"url"`
+	Depth    int    `json:"depth"`
+	Priority int    `json:"priority"`
+	Attempts int    `json:"attempts"`
+	// member raw value in lease set, used for ack
+	member string
+}
+
+type Frontier struct {
+	config    FrontierConfig
+	client    *redis.Client
+	bloomBits uint64
+	bloomK    int
+}
+
+// bloomAddScript set bits of url, return 1 when one of bits was not set (url is new)
+var bloomAddScript = redis.NewScript(`
+local added = 0
+for i = 1, #ARGV do
+	if redis.call('SETBIT', KEYS[1], ARGV[i], 1) == 0 then
+		added = 1
+	end
+end
+return added
+`)
+
+// pushScript add item to queue of its host, host is ready now when it is not in hosts set
+var pushScript = redis.NewScript(`
+if redis.call('ZADD', KEYS[1], 'NX', ARGV[1], ARGV[2]) == 1 then
+	redis.call('ZADD', KEYS[2], 'NX', ARGV[3], ARGV[4])
+	redis.call('INCR', KEYS[3])
+	return 1
+end
+return 0
+`)
+
+// popScript lease first item of first ready host, host is delayed by ARGV[2] ms
+var popScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local hosts = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', now, 'LIMIT', 0, 10)
+for _, host in ipairs(hosts) do
+	local queue = ARGV[4] .. host
+	local items = redis.call('ZPOPMIN', queue)
+	if #items == 0 then
+		redis.call('ZREM', KEYS[1], host)
+	else
+		redis.call('ZADD', KEYS[1], now + tonumber(ARGV[2]), host)
+		redis.call('ZADD', KEYS[2], now + tonumber(ARGV[3]), items[1])
+		redis.call('DECR', KEYS[3])
+		return items[1]
+	end
+end
+return false
+`)
+
+// NewFrontier frontier of config.Name on shared redis client
+func NewFrontier(config FrontierConfig) (*Frontier, error) {
+	redisClient, err := infrastructure.GetRedisClient()
+	if err != nil {
+		return nil, err
+	}
+	bits, k := bloomSize(config.ExpectedItems, config.FalsePositiveRate)
+	return &Frontier{config: config, client: redisClient, bloomBits: bits, bloomK: k}, nil
+}
+
+func (f *Frontier) key(name string) string {
+	return FRONTIER_PREFIX + f.config.Name + ":" + name
+}
+
+// Push add url when its canonical url was not seen before, added is false for seen url.
+// Url is fetched as it is pushed
+func (f *Frontier) Push(ctx context.Context, item FrontierItem) (added bool, err error) {
+	key, err := utils.CanonicalizeUrl(item.Url)
+	if err != nil {
+		return false, nil
+	}
+	item.Url = strings.TrimSpace(item.Url)
+	positions := f.bloomPositions(key)
+	args := make([]interface{}, len(positions))
+	for i, position := range positions {
+		args[i] = position
+	}
+	isNew, err := bloomAddScript.Run(ctx, f.client, []string{f.key("seen")}, args...).Int()
+	if err != nil || isNew == 0 {
+		return false, err
+	}
+	item.Attempts = 0
+	return true, f.enqueue(ctx, item)
+}
+
+// Pop lease next url, item is nil when no host is ready. Expired leases are recovered first
+func (f *Frontier) Pop(ctx context.Context) (item *FrontierItem, err error) {
+	if err = f.RecoverExpired(ctx); err != nil {
+		log.Println("recover frontier lease error:", err)
+	}
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	member, err := popScript.Run(ctx, f.client, []string{f.key("hosts"), f.key("leases"), f.key("size")},
+		now, f.config.HostDelay.Milliseconds(), f.config.LeaseTimeout.Milliseconds(), f.key("queue:")).Text()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	item = &FrontierItem{}
+	if err = json.Unmarshal([]byte(member), item); err != nil {
+		return nil, err
+	}
+	item.member = member
+	return item, nil
+}
+
+// Ack remove leased item after it is crawled
+func (f *Frontier) Ack(ctx context.Context, item *FrontierItem) error {
+	return f.client.ZRem(ctx, f.key("leases"), item.member).Err()
+}
+
+// Nack give leased item back to frontier for retry, item is dropped after MaxAttempts (this was final attempt)
+func (f *Frontier) Nack(ctx context.Context, item *FrontierItem) (dropped bool, err error) {
+	removed, err := f.client.ZRem(ctx, f.key("leases"), item.member).Result()
+	if err != nil || removed == 0 {
+		return false, err
+	}
+	return f.retry(ctx, *item)
+}
+
+// RecoverExpired give back items of crashed or stuck workers
+func (f *Frontier) RecoverExpired(ctx context.Context) error {
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	members, err := f.client.ZRangeByScore(ctx, f.key("leases"), &redis.ZRangeBy{
+		Min: "-inf", Max: strconv.FormatInt(now, 10), Count: 100,
+	}).Result()
+	if err != nil {
+		return err
+	}
+	for _, member := range members {
+		// only one worker can remove the expired lease
+		removed, err := f.client.ZRem(ctx, f.key("leases"), member).Result()
+		if err != nil {
+			return err
+		}
+		if removed == 0 {
+			continue
+		}
+		item := FrontierItem{}
+		if err := json.Unmarshal([]byte(member), &item); err != nil {
+			log.Println("invalid frontier item:", member)
+			continue
+		}
+		log.Println("lease expired:", item.Url)
+		if _, err := f.retry(ctx, item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Pending number of queued and leased urls, crawl is finished when it is 0
+func (f *Frontier) Pending(ctx context.Context) (pending int64, err error) {
+	queued, err := f.client.Get(ctx, f.key("size")).Int64()
+	if err != nil && err != redis.Nil {
+		return 0, err
+	}
+	leased, err := f.client.ZCard(ctx, f.key("leases")).Result()
+	if err != nil {
+		return 0, err
+	}
+	return queued + leased, nil
+}
+
+// Clear delete all keys of frontier (queues, leases and seen urls), frontier can crawl same urls again
+func (f *Frontier) Clear(ctx context.Context) error {
+	var cursor uint64
+	for {
+		keys, next, err := f.client.Scan(ctx, cursor, f.key("*"), 1000).Result()
+		if err != nil {
+			return err
+		}
+		if len(keys) > 0 {
+			if err := f.client.Del(ctx, keys...).Err(); err != nil {
+				return err
+			}
+		}
+		if next == 0 {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+func (f *Frontier) retry(ctx context.Context, item FrontierItem) (dropped bool, err error) {
+	item.Attempts++
+	if f.config.MaxAttempts > 0 && item.Attempts >= f.config.MaxAttempts {
+		log.Println("drop url after", item.Attempts, "attempts:", item.Url)
+		return true, nil
+	}
+	return false, f.enqueue(ctx, item)
+}
+
+func (f *Frontier) enqueue(ctx context.Context, item FrontierItem) error {
+	// urls of same host are in one queue, whatever case, punycode or default port of its host
+	u, err := url.Parse(utils.CanonicalUrlOrRaw(item.Url))
+	if err != nil {
+		return err
+	}
+	host := u.Host
+	member, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	return pushScript.Run(ctx, f.client, []string{f.key("queue:" + host), f.key("hosts"), f.key("size")},
+		-item.Priority, string(member), now, host).Err()
+}
+
+// bloomPositions k bit positions of key using double hashing
+func (f *Frontier) bloomPositions(key string) []uint64 {
+	sum := sha1.Sum([]byte(key))
+	h1 := binary.BigEndian.Uint64(sum[0:8])
+	h2 := binary.BigEndian.Uint64(sum[8:16]) | 1
+	positions := make([]uint64, f.bloomK)
+	for i := range positions {
+		positions[i] = (h1 + uint64(i)*h2) % f.bloomBits
+	}
+	return positions
+}
+
+// bloomSize number of bits and hash functions for n items with false positive rate p
+func bloomSize(n int64, p float64) (bits uint64, k int) {
+	if n <= 0 {
+		n = 1000000
+	}
+	if p <= 0 || p >= 1 {
+		p = 0.001
+	}
+	m := math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2))
+	if m > BLOOM_MAX_BITS {
+		m = BLOOM_MAX_BITS
+	}
+	k = int(math.Round(m / float64(n) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+	return uint64(m), k
+}
+
+// CrawlFrontier worker crawl urls of frontier until it is empty, links in scope of config are pushed back.
+// Seeds of config are pushed first, it is safe to run many workers with same seeds
+func CrawlFrontier(ctx context.Context, frontier *Frontier, config SiteCrawlConfig, options CrawlOptions, emit func(model.CrawlRecord)) error {
+	scope, err := newSiteScope(config)
+	if err != nil {
+		return err
+	}
+	options.CollectLinks = true
+	for _, seed := range config.Seeds {
+		if _, err := frontier.Push(ctx, FrontierItem{Url: strings.TrimSpace(seed)}); err != nil {
+			return err
+		}
+	}
+
+	pages := 0
+	for config.MaxPages <= 0 || pages < config.MaxPages {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		item, err := frontier.Pop(ctx)
+		if err != nil {
+			return err
+		}
+		if item == nil {
+			pending, err := frontier.Pending(ctx)
+			if err != nil {
+				return err
+			}
+			if pending == 0 {
+				return nil
+			}
+			// other hosts are delayed or urls are leased by other workers
+			wait := frontier.config.HostDelay / 2
+			if wait < 100*time.Millisecond {
+				wait = 100 * time.Millisecond
+			}
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+			continue
+		}
+		pages++
+
+		record := model.CrawlRecord{Url: item.Url, Depth: item.Depth}
+		openGraphModel, err := Crawl(ctx, item.Url, options)
+		if err != nil && ctx.Err() != nil {
+			// lease expires, item is crawled again by other worker or next run
+			return ctx.Err()
+		}
+		if err != nil {
+			log.Println("crawl error:", item.Url, err)
+			dropped, errAck := frontier.Nack(ctx, item)
+			if errAck != nil {
+				log.Println("nack frontier item error:", errAck)
+			}
+			// error record is emitted once, after final attempt
+			if dropped {
+				record.Error = err.Error()
+				emit(record)
+			}
+			continue
+		}
+		record.Result = &openGraphModel
+		emit(record)
+
+		if item.Depth < config.MaxDepth {
+			for _, link := range openGraphModel.Links {
+				if !scope.contains(link) {
+					continue
+				}
+				// shallow urls first
+				_, err := frontier.Push(ctx, FrontierItem{Url: link, Depth: item.Depth + 1, Priority: -(item.Depth + 1)})
+				if err != nil {
+					log.Println("push frontier item error:", err)
+				}
+			}
+		}
+		if err := frontier.Ack(ctx, item); err != nil {
+			log.Println("ack frontier item error:", err)
+		}
+	}
+	return nil
+}
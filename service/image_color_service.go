@@ -0,0 +1,29 @@
+package service
+
+import (
+	"crawlweb/model"
+	"crawlweb/utils"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	PALETTE_SIZE          = 5
+	BLURHASH_X_COMPONENTS = 4
+	BLURHASH_Y_COMPONENTS = 3
+)
+
+// GetImageColorInfo compute dominant color, palette and blurhash of image file
+func GetImageColorInfo(filePath string) (colorInfo model.ImageColorInfo, err error) {
+	img, err := decodeImageFile(filePath)
+	if err != nil {
+		return
+	}
+
+	colorInfo.DominantColor, colorInfo.Palette = utils.ExtractColors(img, PALETTE_SIZE)
+	colorInfo.BlurHash, err = utils.EncodeBlurHash(img, BLURHASH_X_COMPONENTS, BLURHASH_Y_COMPONENTS)
+	return
+}
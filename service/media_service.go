@@ -0,0 +1,97 @@
+package service
+
+import (
+	"crawlweb/model"
+	"image"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+const (
+	TYPE_IMAGE = "image"
+	TYPE_PDF   = "pdf"
+	TYPE_AUDIO = "audio"
+	TYPE_VIDEO = "video"
+	TYPE_FILE  = "file"
+)
+
+// IsHtmlContentType check response is html page, empty content type is considered as html
+func IsHtmlContentType(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return true
+	}
+	return mediaType == "text/html" || mediaType == "application/xhtml+xml"
+}
+
+// ParseNonHtml build model from response which is not html page (image, pdf, audio, video, other file)
+func ParseNonHtml(url string, res *http.Response) (openGraphModel model.OpenGraphModel, err error) {
+	mediaType, _, _ := mime.ParseMediaType(res.Header.Get("content-type"))
+	openGraphModel.Url = url
+	openGraphModel.Title = path.Base(res.Request.URL.Path)
+	openGraphModel.ContentType = mediaType
+	if res.ContentLength > 0 {
+		openGraphModel.ContentLength = res.ContentLength
+	}
+
+	switch {
+	case strings.HasPrefix(mediaType, "image/"):
+		openGraphModel.Type = TYPE_IMAGE
+		openGraphModel.Image = url
+		config, _, errConfig := image.DecodeConfig(res.Body)
+		if errConfig == nil {
+			openGraphModel.Width = config.Width
+			openGraphModel.Height = config.Height
+		}
+	case mediaType == "application/pdf":
+		openGraphModel.Type = TYPE_PDF
+		err = parsePdf(res.Body, &openGraphModel)
+	case strings.HasPrefix(mediaType, "audio/"):
+		openGraphModel.Type = TYPE_AUDIO
+	case strings.HasPrefix(mediaType, "video/"):
+		openGraphModel.Type = TYPE_VIDEO
+	default:
+		openGraphModel.Type = TYPE_FILE
+	}
+	return
+}
+
+// parsePdf read title, author and page count from document info
+func parsePdf(body io.Reader, openGraphModel *model.OpenGraphModel) error {
+	filePath := "./temp/" + GenCode() + ".pdf"
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(filePath)
+	defer file.Close()
+	size, err := io.Copy(file, body)
+	if err != nil {
+		return err
+	}
+	if openGraphModel.ContentLength == 0 {
+		openGraphModel.ContentLength = size
+	}
+
+	reader, err := pdf.NewReader(file, size)
+	if err != nil {
+		return err
+	}
+	openGraphModel.PageCount = reader.NumPage()
+	info := reader.Trailer().Key("Info")
+	if title := strings.TrimSpace(info.Key("Title").Text()); title != "" {
+		openGraphModel.Title = title
+	}
+	openGraphModel.Author = strings.TrimSpace(info.Key("Author").Text())
+	openGraphModel.Description = strings.TrimSpace(info.Key("Subject").Text())
+	return nil
+}
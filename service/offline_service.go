@@ -0,0 +1,135 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+var ErrWarcRecordNotFound = errors.New("response record not found in WARC file")
+
+// FileResponse build response from local file (path or file:// url), baseUrl is original url of file
+func FileResponse(ctx context.Context, fileUrl string, baseUrl string) (*http.Response, error) {
+	filePath := fileUrl
+	if u, err := url.Parse(fileUrl); err == nil && u.Scheme == "file" {
+		filePath = u.Path
+	}
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	contentType := mime.TypeByExtension(filepath.Ext(filePath))
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+	if baseUrl == "" {
+		baseUrl = (&url.URL{Scheme: "file", Path: filePath}).String()
+	}
+	return newOfflineResponse(ctx, baseUrl, contentType, data)
+}
+
+// ReaderResponse build response from raw html (ex: stdin), baseUrl is original url of html
+func ReaderResponse(ctx context.Context, r io.Reader, baseUrl string) (*http.Response, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return newOfflineResponse(ctx, baseUrl, "text/html; charset=utf-8", data)
+}
+
+// WarcResponse find response record of targetUrl in WARC file (.warc or .warc.gz), the last record is used
+func WarcResponse(ctx context.Context, warcPath string, targetUrl string) (*http.Response, error) {
+	file, err := os.Open(warcPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var reader io.Reader = file
+	if strings.HasSuffix(warcPath, ".gz") {
+		gzipReader, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, err
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	}
+
+	var block []byte
+	warcReader := textproto.NewReader(bufio.NewReader(reader))
+	for {
+		version, err := warcReader.ReadLine()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if version == "" {
+			continue
+		}
+		if !strings.HasPrefix(version, "WARC/") {
+			return nil, fmt.Errorf("invalid WARC record: %q", version)
+		}
+		header, err := warcReader.ReadMIMEHeader()
+		if err != nil {
+			return nil, err
+		}
+		length, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		data := make([]byte, length)
+		if _, err := io.ReadFull(warcReader.R, data); err != nil {
+			return nil, err
+		}
+		if header.Get("WARC-Type") == "response" && header.Get("WARC-Target-URI") == targetUrl {
+			block = data
+		}
+	}
+	if block == nil {
+		return nil, fmt.Errorf("%w: %s", ErrWarcRecordNotFound, targetUrl)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, targetUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(block)), req)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func newOfflineResponse(ctx context.Context, baseUrl string, contentType string, data []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	header := http.Header{}
+	header.Set("content-type", contentType)
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(data)),
+		ContentLength: int64(len(data)),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,497 @@
+package service
+
+import (
+	"crawlweb/model"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	FORMAT_JSON     = "json"
+	FORMAT_JSONL    = "jsonl"
+	FORMAT_CSV      = "csv"
+	FORMAT_YAML     = "yaml"
+	FORMAT_MARKDOWN = "markdown"
+	FORMAT_HTML     = "html"
+)
+
+// OutputFormats formats of NewRecordWriter
+var OutputFormats = []string{FORMAT_JSON, FORMAT_JSONL, FORMAT_CSV, FORMAT_YAML, FORMAT_MARKDOWN, FORMAT_HTML}
+
+var ErrUnknownFormat = errors.New("unknown output format")
+
+// RecordWriter write records (OpenGraphModel, CrawlRecord, BatchRecord, ...) in one format.
+// Close must be called after last record, it does not close underlying writer
+type RecordWriter interface {
+	Write(record interface{}) error
+	Close() error
+}
+
+// NewRecordWriter writer of format, json is written as array of records
+func NewRecordWriter(w io.Writer, format string) (RecordWriter, error) {
+	switch format {
+	case FORMAT_JSON:
+		return &jsonRecordWriter{w: w}, nil
+	case FORMAT_JSONL:
+		return &jsonlRecordWriter{encoder: json.NewEncoder(w)}, nil
+	case FORMAT_CSV:
+		return &csvRecordWriter{w: csv.NewWriter(w)}, nil
+	case FORMAT_YAML:
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		return &yamlRecordWriter{encoder: encoder}, nil
+	case FORMAT_MARKDOWN:
+		return &markdownRecordWriter{w: w}, nil
+	case FORMAT_HTML:
+		return &htmlRecordWriter{w: w}, nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+}
+
+// WriteRecord write one record, json is written as object instead of array
+func WriteRecord(w io.Writer, format string, record interface{}) error {
+	if format == FORMAT_JSON {
+		data, err := json.MarshalIndent(record, " ", " ")
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(append(data, '\n'))
+		return err
+	}
+	writer, err := NewRecordWriter(w, format)
+	if err != nil {
+		return err
+	}
+	if err := writer.Write(record); err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+// FormatFromPath format of output file by its extension, empty when it is unknown
+func FormatFromPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FORMAT_JSON
+	case ".jsonl", ".ndjson":
+		return FORMAT_JSONL
+	case ".csv":
+		return FORMAT_CSV
+	case ".yaml", ".yml":
+		return FORMAT_YAML
+	case ".md", ".markdown":
+		return FORMAT_MARKDOWN
+	case ".html", ".htm":
+		return FORMAT_HTML
+	}
+	return ""
+}
+
+type jsonRecordWriter struct {
+	w     io.Writer
+	count int
+}
+
+func (writer *jsonRecordWriter) Write(record interface{}) error {
+	data, err := json.MarshalIndent(record, "  ", "  ")
+	if err != nil {
+		return err
+	}
+	separator := ",\n  "
+	if writer.count == 0 {
+		separator = "[\n  "
+	}
+	writer.count++
+	_, err = io.WriteString(writer.w, separator+string(data))
+	return err
+}
+
+func (writer *jsonRecordWriter) Close() error {
+	end := "\n]\n"
+	if writer.count == 0 {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(writer.w, end)
+	return err
+}
+
+type jsonlRecordWriter struct {
+	encoder *json.Encoder
+}
+
+func (writer *jsonlRecordWriter) Write(record interface{}) error {
+	return writer.encoder.Encode(record)
+}
+
+func (writer *jsonlRecordWriter) Close() error { return nil }
+
+// csvRecordWriter one row per record, nested fields are flattened to columns like result.title
+type csvRecordWriter struct {
+	w      *csv.Writer
+	header []string
+}
+
+func (writer *csvRecordWriter) Write(record interface{}) error {
+	fields := flattenRecord(record)
+	if writer.header == nil {
+		for _, field := range fields {
+			writer.header = append(writer.header, field.name)
+		}
+		if err := writer.w.Write(writer.header); err != nil {
+			return err
+		}
+	}
+	row := make([]string, 0, len(fields))
+	for _, field := range fields {
+		row = append(row, csvCell(field.value))
+	}
+	if len(row) != len(writer.header) {
+		return errors.New("csv record has different columns from header")
+	}
+	if err := writer.w.Write(row); err != nil {
+		return err
+	}
+	writer.w.Flush()
+	return writer.w.Error()
+}
+
+func (writer *csvRecordWriter) Close() error {
+	writer.w.Flush()
+	return writer.w.Error()
+}
+
+// csvCell prefix value starting with formula char by "'", spreadsheet does not run crawled text as formula
+func csvCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// yamlRecordWriter one yaml document per record, with same field names as json
+type yamlRecordWriter struct {
+	encoder *yaml.Encoder
+}
+
+func (writer *yamlRecordWriter) Write(record interface{}) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	// json is yaml, decode it to node for keeping order of fields
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return err
+	}
+	resetYamlStyle(&node)
+	return writer.encoder.Encode(&node)
+}
+
+func (writer *yamlRecordWriter) Close() error {
+	return writer.encoder.Close()
+}
+
+// resetYamlStyle block style instead of flow style and quoted strings of json
+func resetYamlStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		resetYamlStyle(child)
+	}
+}
+
+type markdownRecordWriter struct {
+	w     io.Writer
+	count int
+}
+
+func (writer *markdownRecordWriter) Write(record interface{}) error {
+	text := strings.TrimRight(markdownRecord(record), "\n") + "\n"
+	if writer.count > 0 {
+		text = "\n---\n\n" + text
+	}
+	writer.count++
+	_, err := io.WriteString(writer.w, text)
+	return err
+}
+
+func markdownRecord(record interface{}) string {
+	var b strings.Builder
+	url, result, errCrawl := recordPreview(record)
+	if result == nil {
+		if url == "" && errCrawl == "" {
+			// not a crawl result, write its fields
+			for _, field := range flattenRecord(record) {
+				if field.value != "" {
+					fmt.Fprintf(&b, "- **%s**: %s\n", field.name, escapeMarkdown(field.value))
+				}
+			}
+			return b.String()
+		}
+		fmt.Fprintf(&b, "## <%s>\n\n**Error:** %s\n", markdownUrl(url), escapeMarkdown(errCrawl))
+		return b.String()
+	}
+
+	title := result.Title
+	if title == "" {
+		title = url
+	}
+	fmt.Fprintf(&b, "## [%s](%s)\n\n", escapeMarkdown(title), markdownUrl(url))
+	if result.Image != "" {
+		fmt.Fprintf(&b, "![%s](%s)\n\n", escapeMarkdown(title), markdownUrl(result.Image))
+	}
+	if result.Description != "" {
+		fmt.Fprintf(&b, "%s\n\n", escapeMarkdown(result.Description))
+	}
+	items := []struct{ name, value string }{
+		{"Site", result.SiteName},
+		{"Type", result.Type},
+		{"Author", result.Author},
+		{"Price", strings.TrimSpace(result.Price + " " + result.Currency)},
+		{"Final url", result.FinalUrl},
+		{"Content type", result.ContentType},
+	}
+	for _, item := range items {
+		if item.value != "" {
+			fmt.Fprintf(&b, "- **%s**: %s\n", item.name, escapeMarkdown(item.value))
+		}
+	}
+	return b.String()
+}
+
+func (writer *markdownRecordWriter) Close() error { return nil }
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`,
+	"<", "&lt;", ">", "&gt;", "#", `\#`, "|", `\|`, "\n", " ", "\r", "",
+)
+
+func escapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+// markdownUrl url of link or image, parentheses and spaces break markdown link
+func markdownUrl(url string) string {
+	return strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29", "<", "%3C", ">", "%3E").Replace(url)
+}
+
+// htmlRecordWriter html page with one preview card per record
+type htmlRecordWriter struct {
+	w       io.Writer
+	started bool
+}
+
+type htmlCard struct {
+	Url    string
+	Error  string
+	Result *model.OpenGraphModel
+	Title  string
+	Price  string
+}
+
+func (writer *htmlRecordWriter) Write(record interface{}) error {
+	if err := writer.start(); err != nil {
+		return err
+	}
+	url, result, errCrawl := recordPreview(record)
+	card := htmlCard{Url: url, Error: errCrawl, Result: result, Title: url}
+	if result != nil {
+		if result.Title != "" {
+			card.Title = result.Title
+		}
+		card.Price = strings.TrimSpace(result.Price + " " + result.Currency)
+	} else if url == "" && errCrawl == "" {
+		card.Error = "unsupported record"
+	}
+	return htmlCardTemplate.ExecuteTemplate(writer.w, "card", card)
+}
+
+func (writer *htmlRecordWriter) Close() error {
+	if err := writer.start(); err != nil {
+		return err
+	}
+	return htmlCardTemplate.ExecuteTemplate(writer.w, "footer", nil)
+}
+
+func (writer *htmlRecordWriter) start() error {
+	if writer.started {
+		return nil
+	}
+	writer.started = true
+	return htmlCardTemplate.ExecuteTemplate(writer.w, "header", nil)
+}
+
+var htmlCardTemplate = template.Must(template.New("output").Parse(`
+{{- define "header" -}}
+<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Preview</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Roboto, Arial, sans-serif; background: #f3f4f6; margin: 0; padding: 24px; }
+.card { display: flex; max-width: 720px; margin: 0 auto 16px; background: #fff; border: 1px solid #e5e7eb; border-radius: 8px; overflow: hidden; color: inherit; text-decoration: none; }
+.card-image { flex: 0 0 200px; min-height: 140px; background-size: cover; background-position: center; }
+.card-body { padding: 12px 16px; min-width: 0; }
+.card-site { color: #6b7280; font-size: 12px; text-transform: uppercase; }
+.card-title { margin: 4px 0; font-size: 16px; font-weight: 600; color: #111827; }
+.card-description { margin: 0; color: #4b5563; font-size: 14px; }
+.card-meta { margin-top: 8px; color: #6b7280; font-size: 12px; word-break: break-all; }
+.card-error { color: #b91c1c; }
+</style>
+</head>
+<body>
+{{ end -}}
+
+{{- define "card" -}}
+{{- if .Result -}}
+<a class="card" href="{{ .Url }}" target="_blank" rel="noopener noreferrer">
+{{- if .Result.Image }}
+<div class="card-image" style="background-color: {{ .Result.DominantColor }}; background-image: url('{{ .Result.Image }}')"></div>
+{{- end }}
+<div class="card-body">
+{{- if .Result.SiteName }}
+<div class="card-site">{{ .Result.SiteName }}</div>
+{{- end }}
+<div class="card-title">{{ .Title }}</div>
+{{- if .Result.Description }}
+<p class="card-description">{{ .Result.Description }}</p>
+{{- end }}
+<div class="card-meta">{{ if .Price }}{{ .Price }} · {{ end }}{{ .Url }}</div>
+</div>
+</a>
+{{ else -}}
+<div class="card">
+<div class="card-body">
+<div class="card-title">{{ .Title }}</div>
+<p class="card-description card-error">{{ .Error }}</p>
+</div>
+</div>
+{{ end -}}
+{{- end -}}
+
+{{- define "footer" -}}
+</body>
+</html>
+{{ end -}}
+`))
+
+// recordPreview url, result and error of record for markdown and html
+func recordPreview(record interface{}) (url string, result *model.OpenGraphModel, errCrawl string) {
+	switch record := record.(type) {
+	case model.OpenGraphModel:
+		return previewUrl(&record), &record, ""
+	case *model.OpenGraphModel:
+		if record == nil {
+			return "", nil, ""
+		}
+		return previewUrl(record), record, ""
+	case model.CrawlRecord:
+		return record.Url, record.Result, record.Error
+	case *model.CrawlRecord:
+		return record.Url, record.Result, record.Error
+	case model.BatchRecord:
+		return record.Url, record.Result, record.Error
+	case *model.BatchRecord:
+		return record.Url, record.Result, record.Error
+	}
+	return "", nil, ""
+}
+
+func previewUrl(openGraphModel *model.OpenGraphModel) string {
+	for _, url := range []string{openGraphModel.Url, openGraphModel.CanonicalUrl, openGraphModel.FinalUrl} {
+		if url != "" {
+			return url
+		}
+	}
+	return ""
+}
+
+type outputField struct {
+	name  string
+	value string
+}
+
+// flattenRecord fields of record with json names, nested struct fields are named parent.child.
+// Nil pointers are flattened as zero value so every record of same type has same fields
+func flattenRecord(record interface{}) (fields []outputField) {
+	flattenValue("", reflect.ValueOf(record), &fields)
+	return
+}
+
+func flattenValue(name string, value reflect.Value, fields *[]outputField) {
+	if !value.IsValid() {
+		*fields = append(*fields, outputField{name: name})
+		return
+	}
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			value = reflect.Zero(value.Type().Elem())
+		} else {
+			value = value.Elem()
+		}
+	}
+	switch value.Kind() {
+	case reflect.Struct:
+		if _, ok := value.Interface().(fmt.Stringer); ok {
+			break
+		}
+		valueType := value.Type()
+		for i := 0; i < valueType.NumField(); i++ {
+			field := valueType.Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+			tag := strings.Split(field.Tag.Get("json"), ",")[0]
+			if tag == "-" {
+				continue
+			}
+			if field.Anonymous && tag == "" {
+				flattenValue(name, value.Field(i), fields)
+				continue
+			}
+			if tag == "" {
+				tag = field.Name
+			}
+			if name != "" {
+				tag = name + "." + tag
+			}
+			flattenValue(tag, value.Field(i), fields)
+		}
+		return
+	case reflect.Slice, reflect.Array:
+		if value.Type().Elem().Kind() == reflect.String {
+			values := make([]string, value.Len())
+			for i := range values {
+				values[i] = value.Index(i).String()
+			}
+			*fields = append(*fields, outputField{name: name, value: strings.Join(values, "|")})
+			return
+		}
+		if value.Len() == 0 {
+			*fields = append(*fields, outputField{name: name})
+			return
+		}
+		data, _ := json.Marshal(value.Interface())
+		*fields = append(*fields, outputField{name: name, value: string(data)})
+		return
+	case reflect.Map, reflect.Interface:
+		if value.IsNil() {
+			*fields = append(*fields, outputField{name: name})
+			return
+		}
+		data, _ := json.Marshal(value.Interface())
+		*fields = append(*fields, outputField{name: name, value: string(data)})
+		return
+	}
+	*fields = append(*fields, outputField{name: name, value: fmt.Sprint(value.Interface())})
+}
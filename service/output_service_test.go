@@ -0,0 +1,27 @@
+package service
+
+import "testing"
+
+func TestCsvCell(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"", ""},
+		{"Title of article", "Title of article"},
+		{"https://example.com/a", "https://example.com/a"},
+		{"=HYPERLINK(\"https://evil.example\")", "'=HYPERLINK(\"https://evil.example\")"},
+		{"+1+1", "'+1+1"},
+		{"-2+3", "'-2+3"},
+		{"@SUM(A1:A2)", "'@SUM(A1:A2)"},
+		{"\t=1", "'\t=1"},
+		{"\r=1", "'\r=1"},
+		{"a=1", "a=1"},
+		{" =1", " =1"},
+	}
+	for _, test := range tests {
+		if got := csvCell(test.value); got != test.want {
+			t.Errorf("csvCell(%q) = %q, want %q", test.value, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crawlweb/infrastructure"
+	"crawlweb/model"
+	"crawlweb/utils"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	PAGE_CACHE_PREFIX = "page_cache:"
+	// body larger than this is not cached
+	PAGE_CACHE_MAX_BODY_SIZE = 5 << 20
+)
+
+var (
+	PageCacheEnabled = true
+	// freshness of cached page is max-age of Cache-Control, limited by min and max
+	PageCacheMinTTL = 5 * time.Minute
+	PageCacheMaxTTL = 6 * time.Hour
+	// stale entry is kept for revalidating with If-None-Match/If-Modified-Since
+	PageCacheKeep = 7 * 24 * time.Hour
+)
+
+type PageCacheEntry struct {
+	Url           string
+	FinalUrl      string
+	ETag          string
+	LastModified  string
+	ContentType   string
+	Body          []byte
+	RedirectChain []model.RedirectHop
+	ExpiresAt     int64
+	// Results parse results of cached body by resultKey of crawl options, reused when page is not modified
+	Results map[string]*model.OpenGraphModel
+}
+
+// FetchPageCached same as FetchPage but using redis cache.
+// cacheEntry is not nil when body is served from cache (fresh entry or 304 response)
+func FetchPageCached(ctx context.Context, pageUrl string) (res *http.Response, chain []model.RedirectHop, attempts int, cacheEntry *PageCacheEntry, err error) {
+	if !PageCacheEnabled {
+		res, chain, attempts, err = FetchPage(ctx, pageUrl)
+		return
+	}
+
+	entry, errCache := getPageCache(ctx, pageUrl)
+	if errCache != nil {
+		log.Println("get page cache fail:", errCache)
+	}
+	if entry != nil && time.Now().Unix() < entry.ExpiresAt {
+		res, err = entry.toResponse(ctx)
+		return res, entry.RedirectChain, 0, entry, err
+	}
+
+	validators := entry.validators()
+	res, chain, attempts, err = fetchPage(ctx, pageUrl, validators)
+	if err != nil {
+		return
+	}
+
+	ttl := cacheTTL(res.Header.Get("Cache-Control"))
+	if res.StatusCode == http.StatusNotModified && validators.headerFor(res.Request.URL) != nil {
+		res.Body.Close()
+		if ttl > 0 {
+			entry.ExpiresAt = time.Now().Add(ttl).Unix()
+			if errCache := setPageCache(ctx, entry); errCache != nil {
+				log.Println("set page cache fail:", errCache)
+			}
+		}
+		res, err = entry.toResponse(ctx)
+		return res, chain, attempts, entry, err
+	}
+
+	if res.StatusCode != http.StatusOK || !IsHtmlContentType(res.Header.Get("content-type")) {
+		return
+	}
+	if ttl == 0 {
+		// stale entry must not get result of new body
+		if entry != nil {
+			if errCache := deletePageCache(ctx, pageUrl); errCache != nil {
+				log.Println("delete page cache fail:", errCache)
+			}
+		}
+		return
+	}
+	// body of html page is already read by fetchPage
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if len(body) > PAGE_CACHE_MAX_BODY_SIZE {
+		return
+	}
+	errCache = setPageCache(ctx, &PageCacheEntry{
+		Url:           pageUrl,
+		FinalUrl:      res.Request.URL.String(),
+		ETag:          res.Header.Get("ETag"),
+		LastModified:  res.Header.Get("Last-Modified"),
+		ContentType:   res.Header.Get("content-type"),
+		Body:          body,
+		RedirectChain: chain,
+		ExpiresAt:     time.Now().Add(ttl).Unix(),
+	})
+	if errCache != nil {
+		log.Println("set page cache fail:", errCache)
+	}
+	return
+}
+
+// SavePageCacheResult store parse result of options with cached page, result is reused by crawl with same
+// storage and pagination when page is not modified
+func SavePageCacheResult(ctx context.Context, pageUrl string, options CrawlOptions, result model.OpenGraphModel) error {
+	if !PageCacheEnabled {
+		return nil
+	}
+	entry, err := getPageCache(ctx, pageUrl)
+	if err != nil || entry == nil {
+		return err
+	}
+	entry.putResult(options, result)
+	return setPageCache(ctx, entry)
+}
+
+// result parse result of cached body crawled with same storage and pagination as options, nil if there is none
+func (entry *PageCacheEntry) result(options CrawlOptions) *model.OpenGraphModel {
+	if entry == nil {
+		return nil
+	}
+	return entry.Results[resultKey(options)]
+}
+
+func (entry *PageCacheEntry) putResult(options CrawlOptions, result model.OpenGraphModel) {
+	if entry.Results == nil {
+		entry.Results = map[string]*model.OpenGraphModel{}
+	}
+	entry.Results[resultKey(options)] = &result
+}
+
+// resultKey options which change parse result: storage of uploaded image and preview card, pagination
+func resultKey(options CrawlOptions) string {
+	key := uploadStorage(options)
+	if options.FollowPagination {
+		key += ":paginate"
+	}
+	return key
+}
+
+// uploadStorage storage where image and preview card are uploaded, none when upload is skipped
+func uploadStorage(options CrawlOptions) string {
+	if options.SkipUpload {
+		return STORAGE_NONE
+	}
+	if options.Storage == "" {
+		return STORAGE_S3
+	}
+	return options.Storage
+}
+
+func getPageCache(ctx context.Context, pageUrl string) (*PageCacheEntry, error) {
+	redisClient, err := infrastructure.GetRedisClient()
+	if err != nil {
+		return nil, err
+	}
+	value, err := redisClient.Get(ctx, pageCacheKey(pageUrl)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	entry := &PageCacheEntry{}
+	if err := json.Unmarshal(value, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func setPageCache(ctx context.Context, entry *PageCacheEntry) error {
+	value, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	ttl := time.Until(time.Unix(entry.ExpiresAt, 0)) + PageCacheKeep
+	redisClient, err := infrastructure.GetRedisClient()
+	if err != nil {
+		return err
+	}
+	return redisClient.Set(ctx, pageCacheKey(entry.Url), value, ttl).Err()
+}
+
+func deletePageCache(ctx context.Context, pageUrl string) error {
+	redisClient, err := infrastructure.GetRedisClient()
+	if err != nil {
+		return err
+	}
+	return redisClient.Del(ctx, pageCacheKey(pageUrl)).Err()
+}
+
+// validators of cached response, nil when entry is nil or has no ETag and Last-Modified
+func (entry *PageCacheEntry) validators() *pageValidators {
+	if entry == nil || (entry.ETag == "" && entry.LastModified == "") {
+		return nil
+	}
+	finalUrl, err := url.Parse(entry.FinalUrl)
+	if err != nil {
+		return nil
+	}
+	header := http.Header{}
+	if entry.ETag != "" {
+		header.Set("If-None-Match", entry.ETag)
+	}
+	if entry.LastModified != "" {
+		header.Set("If-Modified-Since", entry.LastModified)
+	}
+	return &pageValidators{url: finalUrl, header: header}
+}
+
+func (entry *PageCacheEntry) toResponse(ctx context.Context) (*http.Response, error) {
+	res, err := newOfflineResponse(ctx, entry.FinalUrl, entry.ContentType, entry.Body)
+	if err != nil {
+		return nil, err
+	}
+	res.Header.Set("ETag", entry.ETag)
+	res.Header.Set("Last-Modified", entry.LastModified)
+	return res, nil
+}
+
+// cacheTTL freshness from max-age of Cache-Control, limited by PageCacheMinTTL and PageCacheMaxTTL.
+// 0 when response must not be cached (no-store, no-cache, max-age=0)
+func cacheTTL(cacheControl string) time.Duration {
+	ttl := PageCacheMinTTL
+	for _, directive := range strings.Split(strings.ToLower(cacheControl), ",") {
+		directive = strings.TrimSpace(directive)
+		if directive == "no-store" || directive == "no-cache" || strings.HasPrefix(directive, "no-cache=") {
+			return 0
+		}
+		if strings.HasPrefix(directive, "max-age=") || strings.HasPrefix(directive, "s-maxage=") {
+			seconds, err := strconv.Atoi(strings.Trim(directive[strings.Index(directive, "=")+1:], `"`))
+			if err == nil {
+				if seconds <= 0 {
+					return 0
+				}
+				ttl = time.Duration(seconds) * time.Second
+			}
+		}
+	}
+	if ttl < PageCacheMinTTL {
+		ttl = PageCacheMinTTL
+	}
+	if ttl > PageCacheMaxTTL {
+		ttl = PageCacheMaxTTL
+	}
+	return ttl
+}
+
+// pageCacheKey cache key of canonical url
+func pageCacheKey(pageUrl string) string {
+	return PAGE_CACHE_PREFIX + utils.CanonicalUrlOrRaw(pageUrl)
+}
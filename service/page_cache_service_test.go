@@ -0,0 +1,76 @@
+package service
+
+import (
+	"crawlweb/model"
+	"testing"
+	"time"
+)
+
+// result of not modified page is reused only by crawl with same storage and pagination
+func TestPageCacheEntryResult(t *testing.T) {
+	entry := &PageCacheEntry{}
+	entry.putResult(CrawlOptions{SkipUpload: true}, model.OpenGraphModel{Title: "without upload"})
+
+	tests := []struct {
+		options CrawlOptions
+		want    string
+	}{
+		{CrawlOptions{SkipUpload: true}, "without upload"},
+		{CrawlOptions{Storage: STORAGE_NONE}, "without upload"},
+		{CrawlOptions{SkipUpload: true, CollectLinks: true}, "without upload"},
+		{CrawlOptions{}, ""},
+		{CrawlOptions{Storage: STORAGE_S3}, ""},
+		{CrawlOptions{Storage: STORAGE_DRIVE}, ""},
+		{CrawlOptions{SkipUpload: true, FollowPagination: true}, ""},
+	}
+	for _, tt := range tests {
+		got := ""
+		if result := entry.result(tt.options); result != nil {
+			got = result.Title
+		}
+		if got != tt.want {
+			t.Errorf("result(%+v) = %q, want %q", tt.options, got, tt.want)
+		}
+	}
+
+	entry.putResult(CrawlOptions{}, model.OpenGraphModel{Title: "with upload"})
+	if result := entry.result(CrawlOptions{Storage: STORAGE_S3}); result == nil || result.Title != "with upload" {
+		t.Errorf("result(s3) = %+v, want with upload", result)
+	}
+	if result := entry.result(CrawlOptions{SkipUpload: true}); result == nil || result.Title != "without upload" {
+		t.Errorf("result(none) = %+v, want without upload", result)
+	}
+
+	var missing *PageCacheEntry
+	if result := missing.result(CrawlOptions{}); result != nil {
+		t.Errorf("result of nil entry = %+v, want nil", result)
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	tests := []struct {
+		cacheControl string
+		want         time.Duration
+	}{
+		{"", PageCacheMinTTL},
+		{"public", PageCacheMinTTL},
+		{"max-age=3600", time.Hour},
+		{"public, max-age=7200, must-revalidate", 2 * time.Hour},
+		{"Max-Age=3600", time.Hour},
+		{`max-age="3600"`, time.Hour},
+		{"s-maxage=1800", 30 * time.Minute},
+		{"max-age=10", PageCacheMinTTL},
+		{"max-age=999999", PageCacheMaxTTL},
+		{"max-age=abc", PageCacheMinTTL},
+		{"max-age=0", 0},
+		{"no-store", 0},
+		{"no-cache", 0},
+		{`no-cache="set-cookie", max-age=3600`, 0},
+		{"private, max-age=3600, no-store", 0},
+	}
+	for _, test := range tests {
+		if got := cacheTTL(test.cacheControl); got != test.want {
+			t.Errorf("cacheTTL(%q) = %v, want %v", test.cacheControl, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package service
+
+import (
+	"context"
+	"crawlweb/utils"
+	"log"
+	neturl "net/url"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// PaginationMaxPages max pages of an article, including crawled page
+var PaginationMaxPages = 10
+
+var (
+	// articleSelectors container of article content, first matched selector is used
+	articleSelectors = []string{
+		"[itemprop='articleBody']",
+		".fck_detail",       // vnexpress
+		".singular-content", // dantri
+		".detail-content",   // tuoitre, thanhnien
+		".detail__content",  // znews
+		".article__body",    // vietnamnet
+		".content-detail",   // 24h
+		"#main-detail",      // cafef
+		".article-content",
+		".article-body",
+		".entry-content",
+		".post-content",
+		"article",
+		"main",
+	}
+	articleContentTags = "p, h1, h2, h3, h4, h5, h6, li, blockquote"
+
+	nextPageSelectors = []string{
+		"link[rel~='next']",
+		"a[rel~='next']",
+		".pagination a.next",
+		".pagination .next a",
+		".paging a.next",
+		".page-next a",
+		"a.next-page",
+		"a.next_page",
+	}
+	prevPageSelectors = []string{
+		"link[rel~='prev']",
+		"a[rel~='prev']",
+		".pagination a.prev",
+		".pagination .prev a",
+		".paging a.prev",
+		".page-prev a",
+		"a.prev-page",
+		"a.prev_page",
+	}
+	// paginationContainers elements containing page links, aria-label and text of link are only matched inside them
+	paginationContainers = ".pagination, .paging, .pager, .page-nav, .page-numbers, .wp-pagenavi, nav[aria-label*='agination']"
+	nextPageLabels       = "a[aria-label='Next'], a[aria-label='Next page'], a[aria-label='Trang sau']"
+	prevPageLabels       = "a[aria-label='Previous'], a[aria-label='Previous page'], a[aria-label='Trang trước']"
+	// text of next/previous link when there is no rel or class
+	nextPageTexts = []string{"trang sau", "trang tiếp", "tiếp theo", "next", "»", "›"}
+	prevPageTexts = []string{"trang trước", "previous", "«", "‹"}
+	// pageParams query param of page number (?page=N)
+	pageParams = []string{"page", "paged", "trang", "pg"}
+)
+
+// ExtractArticleContent text of article: paragraphs and headings of article container, separated by blank line
+func ExtractArticleContent(doc *goquery.Document) string {
+	container := doc.Find("body")
+	for _, selector := range articleSelectors {
+		if selection := doc.Find(selector).First(); selection.Length() > 0 {
+			container = selection
+			break
+		}
+	}
+	paragraphs := []string{}
+	container.Find(articleContentTags).Each(func(i int, el *goquery.Selection) {
+		// text of nested tag is already in its parent
+		if el.ParentsFiltered(articleContentTags).Length() > 0 {
+			return
+		}
+		text := strings.Join(strings.Fields(el.Text()), " ")
+		if text != "" {
+			paragraphs = append(paragraphs, text)
+		}
+	})
+	return strings.Join(paragraphs, "\n\n")
+}
+
+// stitchPages follow previous and next pages of article, return content of all pages in order
+// and first page of article (crawled page when it has no previous page), metadata is taken from first page
+func stitchPages(ctx context.Context, doc *goquery.Document, pageUrl *neturl.URL, content string) (stitched string, pages int, firstDoc *goquery.Document, firstUrl *neturl.URL) {
+	seen := map[string]bool{utils.CanonicalUrlOrRaw(pageUrl.String()): true}
+	contents := []string{content}
+	pages = 1
+
+	// previous pages, when crawled page is not first page
+	prevDoc, prevUrl := doc, pageUrl
+	for pages < PaginationMaxPages {
+		link := findPageLink(prevDoc, prevUrl, prevPageSelectors, prevPageLabels, prevPageTexts, -1)
+		page, pageLink, ok := fetchPaginationPage(ctx, link, pageUrl, seen)
+		if !ok {
+			break
+		}
+		contents = append([]string{ExtractArticleContent(page)}, contents...)
+		prevDoc, prevUrl = page, pageLink
+		pages++
+	}
+	// next pages
+	nextDoc, nextUrl := doc, pageUrl
+	for pages < PaginationMaxPages {
+		link := findPageLink(nextDoc, nextUrl, nextPageSelectors, nextPageLabels, nextPageTexts, 1)
+		page, pageLink, ok := fetchPaginationPage(ctx, link, pageUrl, seen)
+		if !ok {
+			break
+		}
+		contents = append(contents, ExtractArticleContent(page))
+		nextDoc, nextUrl = page, pageLink
+		pages++
+	}
+
+	nonEmpty := contents[:0]
+	for _, content := range contents {
+		if content != "" {
+			nonEmpty = append(nonEmpty, content)
+		}
+	}
+	return strings.Join(nonEmpty, "\n\n"), pages, prevDoc, prevUrl
+}
+
+// findPageLink absolute url of pagination link, empty when page has no link.
+// step is 1 for next page, -1 for previous page
+func findPageLink(doc *goquery.Document, base *neturl.URL, selectors []string, labels string, texts []string, step int) string {
+	for _, selector := range selectors {
+		href, exists := doc.Find(selector).First().Attr("href")
+		if exists && strings.TrimSpace(href) != "" {
+			return resolveUrl(base, href)
+		}
+	}
+	// "next", "»"... are common outside pagination (carousel, related articles)
+	containers := doc.Find(paginationContainers)
+	if href, exists := containers.Find(labels).First().Attr("href"); exists && strings.TrimSpace(href) != "" {
+		return resolveUrl(base, href)
+	}
+	link := ""
+	containers.Find("a[href]").EachWithBreak(func(i int, el *goquery.Selection) bool {
+		text := strings.ToLower(strings.TrimSpace(el.Text()))
+		for _, pageText := range texts {
+			if text == pageText {
+				link = resolveUrl(base, el.AttrOr("href", ""))
+				return false
+			}
+		}
+		return true
+	})
+	if link != "" {
+		return link
+	}
+	return findPageParamLink(doc, base, step)
+}
+
+// findPageParamLink link to same path with page number (?page=N) of base plus step
+func findPageParamLink(doc *goquery.Document, base *neturl.URL, step int) string {
+	param, current := pageNumber(base)
+	if current == 0 {
+		// page without page param is first page
+		current = 1
+	}
+	target := current + step
+	if target < 1 {
+		return ""
+	}
+	link := ""
+	doc.Find("a[href]").EachWithBreak(func(i int, el *goquery.Selection) bool {
+		href := resolveUrl(base, el.AttrOr("href", ""))
+		linkUrl, err := neturl.Parse(href)
+		if err != nil || !strings.EqualFold(linkUrl.Hostname(), base.Hostname()) || linkUrl.Path != base.Path {
+			return true
+		}
+		linkParam, number := pageNumber(linkUrl)
+		if param != "" && linkParam != "" && linkParam != param {
+			return true
+		}
+		// link of first page usually has no page param, only trusted inside pagination
+		if number == target || (target == 1 && number == 0 && el.Closest(paginationContainers).Length() > 0) {
+			link = href
+			return false
+		}
+		return true
+	})
+	return link
+}
+
+// pageNumber query param and page number of url, 0 when url has no page param
+func pageNumber(pageUrl *neturl.URL) (param string, number int) {
+	query := pageUrl.Query()
+	for _, name := range pageParams {
+		if value := query.Get(name); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return "", 0
+			}
+			return name, n
+		}
+	}
+	return "", 0
+}
+
+// fetchPaginationPage fetch page of same host as first page, page which is seen before is skipped
+func fetchPaginationPage(ctx context.Context, link string, first *neturl.URL, seen map[string]bool) (doc *goquery.Document, pageUrl *neturl.URL, ok bool) {
+	if link == "" {
+		return nil, nil, false
+	}
+	pageUrl, err := neturl.Parse(link)
+	if err != nil || !strings.EqualFold(pageUrl.Hostname(), first.Hostname()) ||
+		(pageUrl.Scheme != "http" && pageUrl.Scheme != "https") {
+		return nil, nil, false
+	}
+	key := utils.CanonicalUrlOrRaw(link)
+	if seen[key] {
+		return nil, nil, false
+	}
+	seen[key] = true
+
+	res, _, _, err := FetchPage(ctx, link)
+	if err != nil {
+		log.Println("fetch pagination page error:", link, err)
+		return nil, nil, false
+	}
+	defer res.Body.Close()
+	if res.StatusCode != 200 || !IsHtmlContentType(res.Header.Get("content-type")) {
+		log.Println("skip pagination page:", link, res.StatusCode, res.Header.Get("content-type"))
+		return nil, nil, false
+	}
+	// page redirected to page which is seen before (ex: last page redirect to first page)
+	if finalKey := utils.CanonicalUrlOrRaw(res.Request.URL.String()); finalKey != key {
+		if seen[finalKey] {
+			return nil, nil, false
+		}
+		seen[finalKey] = true
+	}
+	doc, err = goquery.NewDocumentFromReader(res.Body)
+	if err != nil {
+		log.Println("parse pagination page error:", link, err)
+		return nil, nil, false
+	}
+	return doc, res.Request.URL, true
+}
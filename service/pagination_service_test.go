@@ -0,0 +1,102 @@
+package service
+
+import (
+	neturl "net/url"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func parseDoc(t *testing.T, html string) *goquery.Document {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestFindPageLink(t *testing.T) {
+	tests := []struct {
+		pageUrl  string
+		html     string
+		wantNext string
+		wantPrev string
+	}{
+		{"https://example.com/a", `<p>no pagination</p>`, "", ""},
+		{"https://example.com/a?page=2", `<head><link rel="next" href="/a?page=3"><link rel="prev" href="/a"></head>`,
+			"https://example.com/a?page=3", "https://example.com/a"},
+		{"https://example.com/a", `<a rel="nofollow next" href="https://example.com/a/2">2</a>`, "https://example.com/a/2", ""},
+		{"https://example.com/a/2", `<div class="pagination"><a class="prev" href="/a">1</a><a class="next" href="/a/3">3</a></div>`,
+			"https://example.com/a/3", "https://example.com/a"},
+		{"https://example.com/a/2", `<nav aria-label="Pagination"><a aria-label="Trang trước" href="/a">x</a><a aria-label="Next" href="/a/3">y</a></nav>`,
+			"https://example.com/a/3", "https://example.com/a"},
+		{"https://example.com/a/2", `<div class="paging"><a href="/a">«</a><a href="/a/3">Trang sau</a></div>`,
+			"https://example.com/a/3", "https://example.com/a"},
+		// text "next" outside pagination is not followed
+		{"https://example.com/a", `<div class="carousel"><a href="/slide/2">Next</a></div>`, "", ""},
+		{"https://example.com/a?page=2", `<a href="/a?page=1">1</a><a href="/a?page=3">3</a><a href="/b?page=3">other</a>`,
+			"https://example.com/a?page=3", "https://example.com/a?page=1"},
+	}
+	for _, test := range tests {
+		base, _ := neturl.Parse(test.pageUrl)
+		doc := parseDoc(t, test.html)
+		if got := findPageLink(doc, base, nextPageSelectors, nextPageLabels, nextPageTexts, 1); got != test.wantNext {
+			t.Errorf("findPageLink(%q, %q, next) = %q, want %q", test.pageUrl, test.html, got, test.wantNext)
+		}
+		if got := findPageLink(doc, base, prevPageSelectors, prevPageLabels, prevPageTexts, -1); got != test.wantPrev {
+			t.Errorf("findPageLink(%q, %q, prev) = %q, want %q", test.pageUrl, test.html, got, test.wantPrev)
+		}
+	}
+}
+
+func TestFindPageParamLink(t *testing.T) {
+	tests := []struct {
+		pageUrl string
+		html    string
+		step    int
+		want    string
+	}{
+		{"https://example.com/a", `<a href="/a?page=2">2</a>`, 1, "https://example.com/a?page=2"},
+		{"https://example.com/a", `<a href="/a?page=2">2</a>`, -1, ""},
+		{"https://example.com/a?page=3", `<a href="/a?page=2">2</a><a href="/a?page=4">4</a>`, -1, "https://example.com/a?page=2"},
+		{"https://example.com/a?page=3", `<a href="/a?page=2">2</a><a href="/a?page=4">4</a>`, 1, "https://example.com/a?page=4"},
+		{"https://example.com/a?page=2", `<a href="https://other.com/a?page=3">3</a>`, 1, ""},
+		{"https://example.com/a?page=2", `<a href="/b?page=3">3</a>`, 1, ""},
+		{"https://example.com/a?page=2", `<a href="/a?trang=3">3</a>`, 1, ""},
+		// link of first page without page param only inside pagination
+		{"https://example.com/a?page=2", `<a href="/a">home</a>`, -1, ""},
+		{"https://example.com/a?page=2", `<ul class="pagination"><li><a href="/a">1</a></li></ul>`, -1, "https://example.com/a"},
+	}
+	for _, test := range tests {
+		base, _ := neturl.Parse(test.pageUrl)
+		if got := findPageParamLink(parseDoc(t, test.html), base, test.step); got != test.want {
+			t.Errorf("findPageParamLink(%q, %q, %d) = %q, want %q", test.pageUrl, test.html, test.step, got, test.want)
+		}
+	}
+}
+
+func TestPageNumber(t *testing.T) {
+	tests := []struct {
+		pageUrl    string
+		wantParam  string
+		wantNumber int
+	}{
+		{"https://example.com/a", "", 0},
+		{"https://example.com/a?page=2", "page", 2},
+		{"https://example.com/a?id=5&paged=3", "paged", 3},
+		{"https://example.com/a?trang=4", "trang", 4},
+		{"https://example.com/a?pg=1", "pg", 1},
+		{"https://example.com/a?page=0", "", 0},
+		{"https://example.com/a?page=-1", "", 0},
+		{"https://example.com/a?page=abc", "", 0},
+		{"https://example.com/a?p=2", "", 0},
+	}
+	for _, test := range tests {
+		u, _ := neturl.Parse(test.pageUrl)
+		param, number := pageNumber(u)
+		if param != test.wantParam || number != test.wantNumber {
+			t.Errorf("pageNumber(%q) = %q, %d, want %q, %d", test.pageUrl, param, number, test.wantParam, test.wantNumber)
+		}
+	}
+}
@@ -0,0 +1,357 @@
+package service
+
+import (
+	"crawlweb/model"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	CARD_TEMPLATE_DEFAULT = "default"
+	CARD_TEMPLATE_COMPACT = "compact"
+	CARD_TEMPLATE_HERO    = "hero"
+
+	DESCRIPTION_EXCERPT_LENGTH = 160
+)
+
+// Font files used to render card, should support Vietnamese (ex: Be Vietnam Pro, Noto Sans).
+// Card is not rendered when file is not found
+var (
+	CardFontRegular = "./storage/fonts/BeVietnamPro-Regular.ttf"
+	CardFontBold    = "./storage/fonts/BeVietnamPro-Bold.ttf"
+)
+
+type CardTemplate struct {
+	Width      int
+	Height     int
+	Padding    int
+	Background color.Color
+	TitleColor color.Color
+	TextColor  color.Color
+	TitleSize  float64
+	TextSize   float64
+	TitleLines int
+	TextLines  int
+	// image on the left side, take ImageRatio of card width
+	ImageRatio float64
+	// image cover all card with a dark overlay
+	ImageBackground bool
+}
+
+var CardTemplates = map[string]CardTemplate{
+	CARD_TEMPLATE_DEFAULT: {
+		Width:      1200,
+		Height:     630,
+		Padding:    48,
+		Background: color.White,
+		TitleColor: color.RGBA{0x1c, 0x1e, 0x21, 0xff},
+		TextColor:  color.RGBA{0x60, 0x67, 0x70, 0xff},
+		TitleSize:  52,
+		TextSize:   28,
+		TitleLines: 3,
+		TextLines:  3,
+		ImageRatio: 0.4,
+	},
+	CARD_TEMPLATE_COMPACT: {
+		Width:      800,
+		Height:     418,
+		Padding:    36,
+		Background: color.RGBA{0xf5, 0xf6, 0xf7, 0xff},
+		TitleColor: color.RGBA{0x1c, 0x1e, 0x21, 0xff},
+		TextColor:  color.RGBA{0x60, 0x67, 0x70, 0xff},
+		TitleSize:  40,
+		TextSize:   24,
+		TitleLines: 3,
+		TextLines:  4,
+	},
+	CARD_TEMPLATE_HERO: {
+		Width:           1200,
+		Height:          630,
+		Padding:         56,
+		Background:      color.Black,
+		TitleColor:      color.White,
+		TextColor:       color.RGBA{0xe4, 0xe6, 0xeb, 0xff},
+		TitleSize:       60,
+		TextSize:        28,
+		TitleLines:      2,
+		TextLines:       2,
+		ImageBackground: true,
+	},
+}
+
+// RenderPreviewCard render social card PNG of openGraphModel, return path of PNG file in temp folder.
+// Caller should remove the file after using it
+func RenderPreviewCard(openGraphModel model.OpenGraphModel, templateName string) (filePath string, err error) {
+	tpl, ok := CardTemplates[templateName]
+	if !ok {
+		err = errors.New("card template not found: " + templateName)
+		return
+	}
+	regularFont, err := loadCardFont(CardFontRegular)
+	if err != nil {
+		return
+	}
+	boldFont, err := loadCardFont(CardFontBold)
+	if err != nil {
+		return
+	}
+
+	card := image.NewRGBA(image.Rect(0, 0, tpl.Width, tpl.Height))
+	draw.Draw(card, card.Bounds(), image.NewUniform(tpl.Background), image.Point{}, draw.Src)
+	if !tpl.ImageBackground && tpl.ImageRatio == 0 {
+		// card without image use dominant color of image as accent bar
+		if c, ok := parseHexColor(openGraphModel.DominantColor); ok {
+			draw.Draw(card, image.Rect(0, 0, 12, tpl.Height), image.NewUniform(c), image.Point{}, draw.Src)
+		}
+	}
+
+	textRect := image.Rect(tpl.Padding, tpl.Padding, tpl.Width-tpl.Padding, tpl.Height-tpl.Padding)
+	img, errImg := loadCardImage(openGraphModel)
+	if errImg != nil {
+		log.Println("load card image fail:", errImg)
+	}
+	if img != nil {
+		if tpl.ImageBackground {
+			drawCover(card, card.Bounds(), img)
+			draw.Draw(card, card.Bounds(), image.NewUniform(color.RGBA{0, 0, 0, 0x99}), image.Point{}, draw.Over)
+		} else if tpl.ImageRatio > 0 {
+			imageWidth := int(float64(tpl.Width) * tpl.ImageRatio)
+			drawCover(card, image.Rect(0, 0, imageWidth, tpl.Height), img)
+			textRect.Min.X = imageWidth + tpl.Padding
+		}
+	}
+
+	// site name and favicon
+	siteName := openGraphModel.SiteName
+	if siteName == "" {
+		siteName = openGraphModel.Url
+	}
+	textFace, err := newCardFace(regularFont, tpl.TextSize)
+	if err != nil {
+		return
+	}
+	defer textFace.Close()
+	titleFace, err := newCardFace(boldFont, tpl.TitleSize)
+	if err != nil {
+		return
+	}
+	defer titleFace.Close()
+
+	y := textRect.Min.Y
+	x := textRect.Min.X
+	if favicon, errFavicon := loadFavicon(openGraphModel.Favicon); errFavicon == nil {
+		size := int(tpl.TextSize)
+		draw.CatmullRom.Scale(card, image.Rect(x, y, x+size, y+size), favicon, favicon.Bounds(), draw.Over, nil)
+		x += size + size/2
+	}
+	if siteName != "" {
+		drawTextLines(card, textFace, tpl.TextColor, x, y, textRect.Max.X-x, []string{siteName}, tpl.TextSize)
+	}
+	y += int(tpl.TextSize * 2)
+
+	// title
+	titleLines := wrapText(titleFace, openGraphModel.Title, textRect.Dx(), tpl.TitleLines)
+	y = drawTextLines(card, titleFace, tpl.TitleColor, textRect.Min.X, y, textRect.Dx(), titleLines, tpl.TitleSize*1.25)
+	y += int(tpl.TextSize)
+
+	// description
+	if y < textRect.Max.Y {
+		maxLines := (textRect.Max.Y - y) / int(tpl.TextSize*1.4)
+		if maxLines > tpl.TextLines {
+			maxLines = tpl.TextLines
+		}
+		textLines := wrapText(textFace, excerpt(openGraphModel.Description, DESCRIPTION_EXCERPT_LENGTH), textRect.Dx(), maxLines)
+		drawTextLines(card, textFace, tpl.TextColor, textRect.Min.X, y, textRect.Dx(), textLines, tpl.TextSize*1.4)
+	}
+
+	filePath = "./temp/" + GenCode() + ".png"
+	file, err := os.Create(filePath)
+	if err != nil {
+		return
+	}
+	err = png.Encode(file, card)
+	if errClose := file.Close(); err == nil {
+		err = errClose
+	}
+	if err != nil {
+		os.Remove(filePath)
+		filePath = ""
+	}
+	return
+}
+
+// RenderAndUploadPreviewCard render card then upload it to bucket
+func RenderAndUploadPreviewCard(openGraphModel model.OpenGraphModel, templateName string) (s3Filename string, etag string, err error) {
+	filePath, err := RenderPreviewCard(openGraphModel, templateName)
+	if err != nil {
+		return
+	}
+	defer os.Remove(filePath)
+	tempFile, err := os.Open(filePath)
+	if err != nil {
+		return
+	}
+	defer tempFile.Close()
+	return UploadFileUsingPresignedUrl(tempFile)
+}
+
+func loadCardFont(filePath string) (*opentype.Font, error) {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("card font not found, download Be Vietnam Pro to %s: %w", filePath, err)
+	}
+	return opentype.Parse(data)
+}
+
+func newCardFace(f *opentype.Font, size float64) (font.Face, error) {
+	return opentype.NewFace(f, &opentype.FaceOptions{
+		Size:    size,
+		DPI:     72,
+		Hinting: font.HintingFull,
+	})
+}
+
+// loadCardImage open stored image of model, download it again if local file is not exist
+func loadCardImage(openGraphModel model.OpenGraphModel) (image.Image, error) {
+	filePath := openGraphModel.Filename
+	if _, err := os.Stat(filePath); filePath == "" || err != nil {
+		if openGraphModel.Image == "" {
+			return nil, nil
+		}
+		filePath = "./temp/" + GenCode()
+		defer os.Remove(filePath)
+		if err := DownloadImage(openGraphModel.Image, filePath); err != nil {
+			return nil, err
+		}
+	}
+	return decodeImageFile(filePath)
+}
+
+func loadFavicon(url string) (image.Image, error) {
+	if url == "" {
+		return nil, errors.New("favicon is EMPTY")
+	}
+	filePath := "./temp/" + GenCode()
+	defer os.Remove(filePath)
+	if err := DownloadImage(url, filePath); err != nil {
+		return nil, err
+	}
+	return decodeImageFile(filePath)
+}
+
+func decodeImageFile(filePath string) (image.Image, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	img, _, err := image.Decode(file)
+	return img, err
+}
+
+// drawCover scale and crop img to fill all rect
+func drawCover(dst draw.Image, rect image.Rectangle, img image.Image) {
+	src := img.Bounds()
+	if src.Dx()*rect.Dy() > src.Dy()*rect.Dx() {
+		// image is wider than rect
+		width := src.Dy() * rect.Dx() / rect.Dy()
+		src.Min.X += (src.Dx() - width) / 2
+		src.Max.X = src.Min.X + width
+	} else {
+		height := src.Dx() * rect.Dy() / rect.Dx()
+		src.Min.Y += (src.Dy() - height) / 2
+		src.Max.Y = src.Min.Y + height
+	}
+	draw.CatmullRom.Scale(dst, rect, img, src, draw.Src, nil)
+}
+
+// wrapText split text to lines fit in maxWidth, add "…" if text is longer than maxLines
+func wrapText(face font.Face, text string, maxWidth int, maxLines int) (lines []string) {
+	words := strings.Fields(text)
+	if len(words) == 0 || maxLines <= 0 {
+		return
+	}
+	line := ""
+	for _, word := range words {
+		next := word
+		if line != "" {
+			next = line + " " + word
+		}
+		if font.MeasureString(face, next).Ceil() <= maxWidth || line == "" {
+			line = next
+			continue
+		}
+		lines = append(lines, line)
+		line = word
+		if len(lines) == maxLines {
+			lines[maxLines-1] = truncateLine(face, lines[maxLines-1], maxWidth, true)
+			return
+		}
+	}
+	lines = append(lines, line)
+	if len(lines) > maxLines {
+		lines = lines[:maxLines]
+		lines[maxLines-1] = truncateLine(face, lines[maxLines-1], maxWidth, true)
+	}
+	return
+}
+
+func truncateLine(face font.Face, line string, maxWidth int, more bool) string {
+	if !more && font.MeasureString(face, line).Ceil() <= maxWidth {
+		return line
+	}
+	runes := []rune(line)
+	for len(runes) > 0 && font.MeasureString(face, string(runes)+"…").Ceil() > maxWidth {
+		runes = runes[:len(runes)-1]
+	}
+	return strings.TrimSpace(string(runes)) + "…"
+}
+
+// drawTextLines draw lines from top y, return y after last line
+func drawTextLines(dst draw.Image, face font.Face, c color.Color, x, y, maxWidth int, lines []string, lineHeight float64) int {
+	ascent := face.Metrics().Ascent.Ceil()
+	drawer := &font.Drawer{
+		Dst:  dst,
+		Src:  image.NewUniform(c),
+		Face: face,
+	}
+	for _, line := range lines {
+		line = truncateLine(face, line, maxWidth, false)
+		drawer.Dot = fixed.P(x, y+ascent)
+		drawer.DrawString(line)
+		y += int(lineHeight)
+	}
+	return y
+}
+
+func excerpt(text string, length int) string {
+	runes := []rune(strings.TrimSpace(text))
+	if len(runes) <= length {
+		return string(runes)
+	}
+	return strings.TrimSpace(string(runes[:length])) + "…"
+}
+
+func parseHexColor(hex string) (color.RGBA, bool) {
+	if len(hex) != 7 || hex[0] != '#' {
+		return color.RGBA{}, false
+	}
+	value, err := strconv.ParseUint(hex[1:], 16, 32)
+	if err != nil {
+		return color.RGBA{}, false
+	}
+	return color.RGBA{uint8(value >> 16), uint8(value >> 8), uint8(value), 0xff}, true
+}
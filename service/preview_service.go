@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"crawlweb/infrastructure"
+	"crawlweb/model"
+	"crawlweb/utils"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"golang.org/x/sync/singleflight"
+)
+
+const PREVIEW_CACHE_PREFIX = "preview:"
+
+var (
+	// PreviewCacheTTL result of url is reused in this time
+	PreviewCacheTTL = time.Hour
+	// PreviewTimeout limit of crawling one url, shared by all coalesced requests
+	PreviewTimeout = 45 * time.Second
+)
+
+var previewGroup singleflight.Group
+
+// GetPreview open graph info of url from redis cache or crawl it.
+// Concurrent requests of same url wait for one crawl, cached is true when result is not crawled by this request
+func GetPreview(ctx context.Context, url string, options CrawlOptions) (openGraphModel model.OpenGraphModel, cached bool, err error) {
+	key := previewCacheKey(url, options)
+	if result, errCache := getPreviewCache(ctx, key); errCache != nil {
+		log.Println("get preview cache fail:", errCache)
+	} else if result != nil {
+		return *result, true, nil
+	}
+
+	resultChan := previewGroup.DoChan(key, func() (interface{}, error) {
+		// crawl is not canceled when first request is canceled, other requests are waiting for it
+		crawlCtx, cancel := context.WithTimeout(context.Background(), PreviewTimeout)
+		defer cancel()
+		result, err := Crawl(crawlCtx, url, options)
+		if err != nil {
+			return nil, err
+		}
+		if errCache := setPreviewCache(crawlCtx, key, result); errCache != nil {
+			log.Println("set preview cache fail:", errCache)
+		}
+		return result, nil
+	})
+	select {
+	case <-ctx.Done():
+		return openGraphModel, false, ctx.Err()
+	case result := <-resultChan:
+		if result.Err != nil {
+			return openGraphModel, false, result.Err
+		}
+		return result.Val.(model.OpenGraphModel), result.Shared, nil
+	}
+}
+
+// previewCacheKey key of url and options which change result, same as results of page cache
+func previewCacheKey(url string, options CrawlOptions) string {
+	return PREVIEW_CACHE_PREFIX + resultKey(options) + ":" + utils.CanonicalUrlOrRaw(url)
+}
+
+func getPreviewCache(ctx context.Context, key string) (*model.OpenGraphModel, error) {
+	redisClient, err := infrastructure.GetRedisClient()
+	if err != nil {
+		return nil, err
+	}
+	value, err := redisClient.Get(ctx, key).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	result := &model.OpenGraphModel{}
+	if err := json.Unmarshal(value, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func setPreviewCache(ctx context.Context, key string, result model.OpenGraphModel) error {
+	value, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	redisClient, err := infrastructure.GetRedisClient()
+	if err != nil {
+		return err
+	}
+	return redisClient.Set(ctx, key, value, PreviewCacheTTL).Err()
+}
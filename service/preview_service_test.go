@@ -0,0 +1,41 @@
+package service
+
+import (
+	"crawlweb/model"
+	"testing"
+)
+
+// preview with skip_upload=true then preview of same url without it must crawl again with upload
+func TestPreviewSkipUploadThenDefault(t *testing.T) {
+	url := "https://example.com/a"
+	skipUpload := CrawlOptions{SkipUpload: true}
+	defaultOptions := CrawlOptions{}
+
+	// first request: page cache keeps result without uploads
+	entry := &PageCacheEntry{Url: url}
+	entry.putResult(skipUpload, model.OpenGraphModel{Title: "a"})
+
+	// second request: not served from preview cache nor from page cache result of first request
+	if previewCacheKey(url, defaultOptions) == previewCacheKey(url, skipUpload) {
+		t.Errorf("previewCacheKey(%q) is same for skip_upload and default", url)
+	}
+	if result := entry.result(defaultOptions); result != nil {
+		t.Errorf("result(default) = %+v, want nil after skip_upload request", result)
+	}
+
+	tests := []struct {
+		options CrawlOptions
+		want    string
+	}{
+		{CrawlOptions{}, "preview:s3:https://example.com/a"},
+		{CrawlOptions{Storage: STORAGE_S3}, "preview:s3:https://example.com/a"},
+		{CrawlOptions{SkipUpload: true}, "preview:none:https://example.com/a"},
+		{CrawlOptions{Storage: STORAGE_DRIVE}, "preview:drive:https://example.com/a"},
+		{CrawlOptions{FollowPagination: true}, "preview:s3:paginate:https://example.com/a"},
+	}
+	for _, tt := range tests {
+		if got := previewCacheKey(url, tt.options); got != tt.want {
+			t.Errorf("previewCacheKey(%q, %+v) = %q, want %q", url, tt.options, got, tt.want)
+		}
+	}
+}
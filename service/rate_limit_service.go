@@ -0,0 +1,265 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"crawlweb/infrastructure"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	RATE_LIMIT_PREFIX  = "rate_limit:"
+	CRAWL_DELAY_PREFIX = "crawl_delay:"
+	CRAWL_DELAY_TTL    = 24 * time.Hour
+	ROBOTS_AGENT       = "crawlweb"
+
+	// robots.txt which can not be fetched (network error, 429, 5xx) is fetched again after this time
+	CRAWL_DELAY_ERROR_TTL = 10 * time.Minute
+)
+
+// tokenBucketScript take one token from bucket, return milliseconds to wait when bucket is empty.
+// Time of redis server is used, clocks of workers may be different
+var tokenBucketScript = redis.NewScript(`
+redis.replicate_commands()
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local data = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(data[1])
+local ts = tonumber(data[2])
+if tokens == nil or ts == nil then
+	tokens = burst
+	ts = now
+end
+tokens = math.min(burst, tokens + math.max(0, now - ts) / 1000 * rate)
+local wait = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+else
+	wait = math.ceil((1 - tokens) / rate * 1000)
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst / rate * 1000) + 1000)
+return wait
+`)
+
+type localBucket struct {
+	tokens float64
+	ts     time.Time
+}
+
+var (
+	localBuckets     = map[string]*localBucket{}
+	localCrawlDelays = map[string]crawlDelayEntry{}
+	localMutex       sync.Mutex
+)
+
+type crawlDelayEntry struct {
+	delay     time.Duration
+	expiresAt time.Time
+}
+
+// WaitRateLimit block until host of u has a token in its bucket
+func WaitRateLimit(ctx context.Context, u *url.URL) error {
+	config := infrastructure.GetFetcherConfig().RateLimit
+	if config.Mode == infrastructure.RATE_LIMIT_OFF || config.Rate <= 0 {
+		return nil
+	}
+	host := strings.ToLower(u.Host)
+	rate, burst := config.Rate, config.Burst
+	if burst < 1 {
+		burst = 1
+	}
+	if config.RespectCrawlDelay {
+		delay := getCrawlDelay(ctx, config.Mode, u)
+		if delay > 0 && 1/delay.Seconds() < rate {
+			rate, burst = 1/delay.Seconds(), 1
+		}
+	}
+
+	for {
+		var wait time.Duration
+		var err error
+		if config.Mode == infrastructure.RATE_LIMIT_REDIS {
+			wait, err = takeRedisToken(ctx, host, rate, burst)
+			if err != nil {
+				// do not block crawling when redis is down
+				log.Println("rate limit redis error:", err)
+				return nil
+			}
+		} else {
+			wait = takeLocalToken(host, rate, burst)
+		}
+		if wait <= 0 {
+			return nil
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func takeRedisToken(ctx context.Context, host string, rate float64, burst int) (time.Duration, error) {
+	redisClient, err := infrastructure.GetRedisClient()
+	if err != nil {
+		return 0, err
+	}
+	wait, err := tokenBucketScript.Run(ctx, redisClient, []string{RATE_LIMIT_PREFIX + host}, rate, burst).Int64()
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(wait) * time.Millisecond, nil
+}
+
+func takeLocalToken(host string, rate float64, burst int) time.Duration {
+	localMutex.Lock()
+	defer localMutex.Unlock()
+	now := time.Now()
+	bucket, ok := localBuckets[host]
+	if !ok {
+		bucket = &localBucket{tokens: float64(burst), ts: now}
+		localBuckets[host] = bucket
+	}
+	bucket.tokens = math.Min(float64(burst), bucket.tokens+now.Sub(bucket.ts).Seconds()*rate)
+	bucket.ts = now
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return 0
+	}
+	return time.Duration(math.Ceil((1 - bucket.tokens) / rate * float64(time.Second)))
+}
+
+// getCrawlDelay Crawl-delay of robots.txt, cached in redis or memory
+func getCrawlDelay(ctx context.Context, mode string, u *url.URL) time.Duration {
+	host := strings.ToLower(u.Host)
+	if mode == infrastructure.RATE_LIMIT_REDIS {
+		redisClient, err := infrastructure.GetRedisClient()
+		if err != nil {
+			// do not block crawling when redis is down
+			log.Println("get crawl delay error:", err)
+			return 0
+		}
+		value, err := redisClient.Get(ctx, CRAWL_DELAY_PREFIX+host).Result()
+		if err == nil {
+			delay, _ := time.ParseDuration(value)
+			return delay
+		}
+	} else {
+		localMutex.Lock()
+		entry, ok := localCrawlDelays[host]
+		localMutex.Unlock()
+		if ok && time.Now().Before(entry.expiresAt) {
+			return entry.delay
+		}
+	}
+
+	delay, ok := fetchCrawlDelay(ctx, u)
+	ttl := CRAWL_DELAY_TTL
+	if !ok {
+		ttl = CRAWL_DELAY_ERROR_TTL
+	}
+	if mode == infrastructure.RATE_LIMIT_REDIS {
+		redisClient, err := infrastructure.GetRedisClient()
+		if err == nil {
+			err = redisClient.Set(ctx, CRAWL_DELAY_PREFIX+host, delay.String(), ttl).Err()
+		}
+		if err != nil {
+			log.Println("save crawl delay error:", err)
+		}
+	} else {
+		localMutex.Lock()
+		localCrawlDelays[host] = crawlDelayEntry{delay: delay, expiresAt: time.Now().Add(ttl)}
+		localMutex.Unlock()
+	}
+	return delay
+}
+
+// fetchCrawlDelay read Crawl-delay of our agent (or *) from robots.txt, 0 if not found.
+// ok is false when robots.txt can not be fetched (network error, 429, 5xx)
+func fetchCrawlDelay(ctx context.Context, u *url.URL) (delay time.Duration, ok bool) {
+	robotsUrl := url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/robots.txt"}
+	res, err := fetch(ctx, infrastructure.GetHttpClient(), robotsUrl.String(), nil, nil, false)
+	if err != nil {
+		log.Println("fetch robots.txt error:", robotsUrl.String(), err)
+		return 0, false
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500 {
+		return 0, false
+	}
+	// robots.txt is not found (4xx), host has no crawl delay
+	if res.StatusCode != http.StatusOK {
+		return 0, true
+	}
+	return parseCrawlDelay(io.LimitReader(res.Body, 512<<10), ROBOTS_AGENT), true
+}
+
+func parseCrawlDelay(robots io.Reader, agent string) time.Duration {
+	var agentDelay, defaultDelay float64 = -1, -1
+	var groupAgents []string
+	inRules := false
+	scanner := bufio.NewScanner(robots)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(parts[0]))
+		value := strings.TrimSpace(parts[1])
+		switch key {
+		case "user-agent":
+			// empty agent would match every agent
+			if value == "" {
+				continue
+			}
+			// new group start after rules of previous group
+			if inRules {
+				groupAgents = nil
+				inRules = false
+			}
+			groupAgents = append(groupAgents, strings.ToLower(value))
+		case "crawl-delay":
+			inRules = true
+			delay, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			for _, groupAgent := range groupAgents {
+				if groupAgent == "*" {
+					defaultDelay = delay
+				} else if strings.Contains(agent, groupAgent) {
+					agentDelay = delay
+				}
+			}
+		default:
+			inRules = true
+		}
+	}
+	delay := defaultDelay
+	if agentDelay >= 0 {
+		delay = agentDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return time.Duration(delay * float64(time.Second))
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCrawlDelay(t *testing.T) {
+	tests := []struct {
+		robots string
+		want   time.Duration
+	}{
+		{"", 0},
+		{"User-agent: *\nDisallow: /private", 0},
+		{"User-agent: *\nCrawl-delay: 2", 2 * time.Second},
+		{"User-agent: *\nCrawl-delay: 0.5", 500 * time.Millisecond},
+		{"user-agent: *\ncrawl-delay: 3 # seconds", 3 * time.Second},
+		{"User-agent: *\nCrawl-delay: 10\n\nUser-agent: crawlweb\nCrawl-delay: 1", time.Second},
+		{"User-agent: crawlweb\nCrawl-delay: 1\n\nUser-agent: *\nCrawl-delay: 10", time.Second},
+		{"User-agent: CrawlWeb\nCrawl-delay: 4", 4 * time.Second},
+		{"User-agent: otherbot\nCrawl-delay: 5", 0},
+		{"User-agent: otherbot\nUser-agent: crawlweb\nCrawl-delay: 5", 5 * time.Second},
+		{"User-agent: crawlweb\nDisallow: /a\nUser-agent: otherbot\nCrawl-delay: 5", 0},
+		{"User-agent: *\nCrawl-delay: soon", 0},
+		{"User-agent: *\nCrawl-delay: -1", 0},
+		{"User-agent:\nCrawl-delay: 5", 0},
+		{"# User-agent: *\n# Crawl-delay: 5", 0},
+	}
+	for _, test := range tests {
+		if got := parseCrawlDelay(strings.NewReader(test.robots), ROBOTS_AGENT); got != test.want {
+			t.Errorf("parseCrawlDelay(%q) = %v, want %v", test.robots, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crawlweb/infrastructure"
+	"crawlweb/model"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const (
+	MAX_REDIRECTS = 10
+	// meta refresh with longer delay is a normal page, not a redirect
+	MAX_META_REFRESH_DELAY = 10
+	// only follow javascript redirect of nearly empty page
+	MAX_JS_REDIRECT_TEXT_LENGTH = 1024
+
+	REDIRECT_HTTP       = "http"
+	REDIRECT_META       = "meta-refresh"
+	REDIRECT_JAVASCRIPT = "javascript"
+)
+
+var (
+	ErrRedirectLoop     = errors.New("redirect loop detected")
+	ErrTooManyRedirects = errors.New("too many redirects")
+
+	metaRefreshUrlRegex = regexp.MustCompile(`(?i)^\s*(\d*\.?\d*)\s*(?:[;,]\s*(?:url\s*=\s*)?['"]?([^'"]*)['"]?)?`)
+	jsRedirectRegex     = regexp.MustCompile(`(?:window\.|document\.|top\.|self\.)?location(?:\.href)?\s*=\s*["']([^"']+)["']|location\.(?:replace|assign)\(\s*["']([^"']+)["']\s*\)`)
+)
+
+// FetchPage fetch url, follow http redirect, meta refresh and javascript redirect, retry on error.
+// Body of html page is read and can be read again from res.Body
+func FetchPage(ctx context.Context, pageUrl string) (res *http.Response, chain []model.RedirectHop, attempts int, err error) {
+	return fetchPage(ctx, pageUrl, nil)
+}
+
+// pageValidators If-None-Match/If-Modified-Since of cached response, only sent to url of that response
+type pageValidators struct {
+	url    *url.URL
+	header http.Header
+}
+
+// headerFor validators when u is url of cached response, nil for other urls (redirect hops)
+func (validators *pageValidators) headerFor(u *url.URL) http.Header {
+	if validators == nil || redirectKey(validators.url) != redirectKey(u) {
+		return nil
+	}
+	return validators.header
+}
+
+func fetchPage(ctx context.Context, pageUrl string, validators *pageValidators) (res *http.Response, chain []model.RedirectHop, attempts int, err error) {
+	seen := map[string]bool{}
+	client := *infrastructure.GetHttpClient()
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		chain = append(chain, model.RedirectHop{
+			Type:       REDIRECT_HTTP,
+			Url:        req.Response.Request.URL.String(),
+			StatusCode: req.Response.StatusCode,
+			Location:   req.Response.Header.Get("Location"),
+		})
+		if len(chain) >= MAX_REDIRECTS {
+			return ErrTooManyRedirects
+		}
+		if seen[redirectKey(req.URL)] {
+			return ErrRedirectLoop
+		}
+		seen[redirectKey(req.URL)] = true
+		// header of first request is copied to redirect request
+		req.Header.Del("If-None-Match")
+		req.Header.Del("If-Modified-Since")
+		for key, values := range validators.headerFor(req.URL) {
+			req.Header[key] = values
+		}
+		return infrastructure.CheckUrl(req.Context(), req.URL)
+	}
+
+	for {
+		currentUrl, errParse := url.Parse(pageUrl)
+		if errParse != nil {
+			return nil, chain, attempts, errParse
+		}
+		seen[redirectKey(currentUrl)] = true
+		// http redirects of failed attempt are not kept
+		startChain, startSeen := len(chain), copySeen(seen)
+		var hopAttempts int
+		res, hopAttempts, err = FetchWithRetry(ctx, func() (*http.Response, error) {
+			chain, seen = chain[:startChain], copySeen(startSeen)
+			return FetchWithHeader(ctx, &client, pageUrl, validators.headerFor(currentUrl), infrastructure.GetFetcherConfig().AllowedPageContentTypes)
+		})
+		attempts += hopAttempts
+		if err != nil {
+			return
+		}
+		if res.StatusCode != http.StatusOK || !IsHtmlContentType(res.Header.Get("content-type")) {
+			return
+		}
+
+		body, errRead := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if errRead != nil {
+			return nil, chain, attempts, errRead
+		}
+		res.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		redirectType, location := findClientRedirect(body)
+		if location == "" {
+			return
+		}
+		target, errParse := res.Request.URL.Parse(location)
+		if errParse != nil || (target.Scheme != "http" && target.Scheme != "https") {
+			return
+		}
+		chain = append(chain, model.RedirectHop{
+			Type:       redirectType,
+			Url:        res.Request.URL.String(),
+			StatusCode: res.StatusCode,
+			Location:   location,
+		})
+		if len(chain) >= MAX_REDIRECTS {
+			return nil, chain, attempts, ErrTooManyRedirects
+		}
+		if seen[redirectKey(target)] {
+			return nil, chain, attempts, ErrRedirectLoop
+		}
+		pageUrl = target.String()
+	}
+}
+
+// findClientRedirect find meta refresh or javascript redirect in html page
+func findClientRedirect(body []byte) (redirectType string, location string) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+	doc.Find("meta").EachWithBreak(func(i int, el *goquery.Selection) bool {
+		httpEquiv, _ := el.Attr("http-equiv")
+		if !strings.EqualFold(strings.TrimSpace(httpEquiv), "refresh") {
+			return true
+		}
+		content, _ := el.Attr("content")
+		match := metaRefreshUrlRegex.FindStringSubmatch(content)
+		if match == nil || strings.TrimSpace(match[2]) == "" {
+			return true
+		}
+		if delay, err := strconv.ParseFloat(match[1], 64); err == nil && delay > MAX_META_REFRESH_DELAY {
+			return true
+		}
+		redirectType, location = REDIRECT_META, strings.TrimSpace(match[2])
+		return false
+	})
+	if location != "" {
+		return
+	}
+
+	text := doc.Find("body").Clone()
+	text.Find("script, noscript, style").Remove()
+	if len(strings.TrimSpace(text.Text())) > MAX_JS_REDIRECT_TEXT_LENGTH {
+		return
+	}
+	doc.Find("script").EachWithBreak(func(i int, el *goquery.Selection) bool {
+		match := jsRedirectRegex.FindStringSubmatch(el.Text())
+		if match == nil {
+			return true
+		}
+		location = match[1]
+		if location == "" {
+			location = match[2]
+		}
+		redirectType = REDIRECT_JAVASCRIPT
+		return false
+	})
+	return
+}
+
+func copySeen(seen map[string]bool) map[string]bool {
+	result := make(map[string]bool, len(seen))
+	for k, v := range seen {
+		result[k] = v
+	}
+	return result
+}
+
+func redirectKey(u *url.URL) string {
+	key := *u
+	key.Fragment = ""
+	return strings.ToLower(key.Scheme+"://"+key.Host) + key.RequestURI()
+}
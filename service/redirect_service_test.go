@@ -0,0 +1,38 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFindClientRedirect(t *testing.T) {
+	longText := "<p>" + strings.Repeat("article text ", 100) + "</p>"
+	tests := []struct {
+		html         string
+		wantType     string
+		wantLocation string
+	}{
+		{`<html><head><title>a</title></head><body>text</body></html>`, "", ""},
+		{`<meta http-equiv="refresh" content="0; url=https://example.com/b">`, REDIRECT_META, "https://example.com/b"},
+		{`<meta http-equiv="Refresh" content="0;URL='/b'">`, REDIRECT_META, "/b"},
+		{`<meta http-equiv="refresh" content="5, /b">`, REDIRECT_META, "/b"},
+		{`<meta http-equiv="refresh" content="2.5; url=/b">`, REDIRECT_META, "/b"},
+		{`<meta http-equiv="refresh" content="30; url=/b">`, "", ""},
+		{`<meta http-equiv="refresh" content="60">`, "", ""},
+		{`<meta http-equiv="refresh" content="0; url=">`, "", ""},
+		{`<meta name="refresh" content="0; url=/b">`, "", ""},
+		{`<script>window.location.href = "https://example.com/b";</script>`, REDIRECT_JAVASCRIPT, "https://example.com/b"},
+		{`<script>location = '/b'</script>`, REDIRECT_JAVASCRIPT, "/b"},
+		{`<script>location.replace("/b")</script>`, REDIRECT_JAVASCRIPT, "/b"},
+		{`<script>document.location.assign('/b')</script>`, REDIRECT_JAVASCRIPT, "/b"},
+		{`<body>` + longText + `<script>location.href = "/b"</script></body>`, "", ""},
+		{`<script>if (location.href == "/b") {}</script>`, "", ""},
+		{`<meta http-equiv="refresh" content="0; url=/meta"><script>location = "/js"</script>`, REDIRECT_META, "/meta"},
+	}
+	for _, test := range tests {
+		gotType, gotLocation := findClientRedirect([]byte(test.html))
+		if gotType != test.wantType || gotLocation != test.wantLocation {
+			t.Errorf("findClientRedirect(%q) = %q, %q, want %q, %q", test.html, gotType, gotLocation, test.wantType, test.wantLocation)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"crawlweb/infrastructure"
+	"errors"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// FetchWithRetry call fetch until it success or reach max attempts of retry policy.
+// Response of last attempt is returned even if its status is retryable
+func FetchWithRetry(ctx context.Context, fetch func() (*http.Response, error)) (res *http.Response, attempts int, err error) {
+	policy := infrastructure.GetFetcherConfig().Retry
+	maxAttempts := policy.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	for attempts = 1; ; attempts++ {
+		res, err = fetch()
+		if attempts >= maxAttempts || !isRetryable(res, err, policy.RetryStatuses) {
+			return
+		}
+
+		delay := backoffDelay(policy, attempts)
+		if res != nil {
+			if retryAfter, ok := parseRetryAfter(res.Header.Get("Retry-After")); ok {
+				delay = retryAfter
+				if policy.MaxDelay > 0 && delay > policy.MaxDelay {
+					delay = policy.MaxDelay
+				}
+			}
+			log.Printf("fetch %v got status %d, retry after %v\n", res.Request.URL, res.StatusCode, delay)
+			res.Body.Close()
+		} else {
+			log.Printf("fetch error: %v, retry after %v\n", err, delay)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, attempts, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func isRetryable(res *http.Response, err error, retryStatuses []int) bool {
+	if err != nil {
+		// errors which is same on every attempt
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) ||
+			errors.Is(err, ErrContentTypeNotAllowed) || errors.Is(err, ErrBodyTooLarge) ||
+			errors.Is(err, ErrRedirectLoop) || errors.Is(err, ErrTooManyRedirects) ||
+			errors.Is(err, infrastructure.ErrUrlNotAllowed) {
+			return false
+		}
+		return true
+	}
+	for _, status := range retryStatuses {
+		if res.StatusCode == status {
+			return true
+		}
+	}
+	return false
+}
+
+// backoffDelay exponential backoff with full jitter
+func backoffDelay(policy infrastructure.RetryPolicy, attempt int) time.Duration {
+	delay := policy.BaseDelay << uint(attempt-1)
+	if delay <= 0 || (policy.MaxDelay > 0 && delay > policy.MaxDelay) {
+		delay = policy.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(delay)))
+}
+
+// parseRetryAfter parse Retry-After header, value is seconds or http date
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		delay := time.Until(date)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+	return 0, false
+}
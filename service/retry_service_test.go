@@ -0,0 +1,62 @@
+package service
+
+import (
+	"crawlweb/infrastructure"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		value  string
+		want   time.Duration
+		wantOk bool
+	}{
+		{"", 0, false},
+		{"0", 0, true},
+		{"120", 2 * time.Minute, true},
+		{"-5", 0, false},
+		{"1.5", 0, false},
+		{"soon", 0, false},
+		{now.Add(time.Hour).UTC().Format(http.TimeFormat), time.Hour, true},
+		{now.Add(-time.Hour).UTC().Format(http.TimeFormat), 0, true},
+		{"Mon, 32 Foo 2020 25:00:00 GMT", 0, false},
+	}
+	for _, test := range tests {
+		got, ok := parseRetryAfter(test.value)
+		if ok != test.wantOk {
+			t.Errorf("parseRetryAfter(%q) ok = %v, want %v", test.value, ok, test.wantOk)
+			continue
+		}
+		// http date has second precision and time passes while testing
+		if diff := got - test.want; diff < -2*time.Second || diff > 2*time.Second {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", test.value, got, test.want)
+		}
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	tests := []struct {
+		policy  infrastructure.RetryPolicy
+		attempt int
+		max     time.Duration
+	}{
+		{infrastructure.RetryPolicy{BaseDelay: 500 * time.Millisecond, MaxDelay: 30 * time.Second}, 1, 500 * time.Millisecond},
+		{infrastructure.RetryPolicy{BaseDelay: 500 * time.Millisecond, MaxDelay: 30 * time.Second}, 3, 2 * time.Second},
+		{infrastructure.RetryPolicy{BaseDelay: 500 * time.Millisecond, MaxDelay: 30 * time.Second}, 10, 30 * time.Second},
+		{infrastructure.RetryPolicy{BaseDelay: 500 * time.Millisecond, MaxDelay: 30 * time.Second}, 100, 30 * time.Second},
+		{infrastructure.RetryPolicy{BaseDelay: time.Second}, 4, 8 * time.Second},
+		{infrastructure.RetryPolicy{}, 1, 0},
+	}
+	for _, test := range tests {
+		for i := 0; i < 100; i++ {
+			got := backoffDelay(test.policy, test.attempt)
+			if got < 0 || (got >= test.max && test.max > 0) || (test.max == 0 && got != 0) {
+				t.Errorf("backoffDelay(%+v, %d) = %v, want in [0, %v)", test.policy, test.attempt, got, test.max)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"crawlweb/infrastructure"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+var (
+	loginMutex sync.Mutex
+	// profiles logged in by this process, used when profile has no SuccessCookie
+	loggedIn = map[string]bool{}
+)
+
+// applySession add static auth headers of session profile and login if needed
+func applySession(ctx context.Context, req *http.Request) error {
+	profile := infrastructure.GetSessionProfile(req.URL.Host)
+	if profile == nil {
+		return nil
+	}
+	for key, value := range profile.Headers {
+		req.Header.Set(key, value)
+	}
+	if profile.Login == nil {
+		return nil
+	}
+	loginMutex.Lock()
+	defer loginMutex.Unlock()
+	if profile.Login.SuccessCookie == "" && loggedIn[profile.Domain] {
+		return nil
+	}
+	if profile.Login.SuccessCookie != "" && infrastructure.GetSessionCookie(profile, profile.Login.SuccessCookie) != "" {
+		return nil
+	}
+	err := LoginSession(ctx, profile)
+	if err != nil {
+		return err
+	}
+	loggedIn[profile.Domain] = true
+	return nil
+}
+
+// LoginSession open login page, fill fields to login form (keep hidden inputs like csrf token) and submit it.
+// Cookies are stored in jar of shared http client
+func LoginSession(ctx context.Context, profile *infrastructure.SessionProfile) error {
+	login := profile.Login
+	client := infrastructure.GetHttpClient()
+	config := infrastructure.GetFetcherConfig()
+
+	loginUrl, err := url.Parse(login.Url)
+	if err != nil {
+		return err
+	}
+	if err := infrastructure.CheckUrl(ctx, loginUrl); err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, login.Url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", config.UserAgent)
+	for key, value := range profile.Headers {
+		req.Header.Set(key, value)
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	doc, err := goquery.NewDocumentFromReader(res.Body)
+	if err != nil {
+		return err
+	}
+
+	selector := login.FormSelector
+	if selector == "" {
+		selector = "form"
+	}
+	form := doc.Find(selector).First()
+	if form.Length() == 0 {
+		return errors.New("login form not found: " + selector)
+	}
+	values := url.Values{}
+	form.Find("input[name]").Each(func(i int, el *goquery.Selection) {
+		name, _ := el.Attr("name")
+		value, _ := el.Attr("value")
+		values.Set(name, value)
+	})
+	for key, value := range login.Fields {
+		values.Set(key, value)
+	}
+	action, _ := form.Attr("action")
+	actionUrl, err := res.Request.URL.Parse(action)
+	if err != nil {
+		return err
+	}
+	method := strings.ToUpper(form.AttrOr("method", http.MethodPost))
+
+	var submit *http.Request
+	if method == http.MethodGet {
+		actionUrl.RawQuery = values.Encode()
+		submit, err = http.NewRequestWithContext(ctx, http.MethodGet, actionUrl.String(), nil)
+	} else {
+		submit, err = http.NewRequestWithContext(ctx, http.MethodPost, actionUrl.String(), strings.NewReader(values.Encode()))
+		if err == nil {
+			submit.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+	}
+	if err != nil {
+		return err
+	}
+	submit.Header.Set("User-Agent", config.UserAgent)
+	submit.Header.Set("Referer", res.Request.URL.String())
+	for key, value := range profile.Headers {
+		submit.Header.Set(key, value)
+	}
+	submitRes, err := client.Do(submit)
+	if err != nil {
+		return err
+	}
+	submitRes.Body.Close()
+
+	if login.SuccessCookie != "" && infrastructure.GetSessionCookie(profile, login.SuccessCookie) == "" {
+		return fmt.Errorf("login %s fail, cookie %s not found (status %d)", profile.Domain, login.SuccessCookie, submitRes.StatusCode)
+	}
+	return nil
+}
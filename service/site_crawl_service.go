@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"crawlweb/model"
+	"crawlweb/utils"
+	"errors"
+	"log"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const (
+	SCOPE_HOST   = "host"
+	SCOPE_DOMAIN = "domain"
+	SCOPE_ANY    = "any"
+)
+
+type SiteCrawlConfig struct {
+	Seeds    []string
+	MaxDepth int
+	MaxPages int
+	// Scope host: same host as seeds, domain: host of seeds and its subdomains, any: all hosts
+	Scope      string
+	PathPrefix string
+	// IncludePattern, ExcludePattern regex of url to follow
+	IncludePattern string
+	ExcludePattern string
+	// MaxPagesPerHost 0 is unlimited
+	MaxPagesPerHost int
+}
+
+type siteScope struct {
+	config  SiteCrawlConfig
+	hosts   map[string]bool
+	include *regexp.Regexp
+	exclude *regexp.Regexp
+}
+
+// CrawlState progress of site crawl, it is saved by checkpoint for resuming crawl
+type CrawlState struct {
+	Queue []CrawlStateItem
+	// Seen canonical urls which are queued or crawled
+	Seen  map[string]bool
+	Pages int
+	// HostPages number of crawled pages of each host
+	HostPages map[string]int
+}
+
+// CrawlStateItem queued url, Url is fetched as it is, canonical url is only key of Seen
+type CrawlStateItem struct {
+	Url   string
+	Depth int
+}
+
+// newCrawlState state with seeds in queue, seeds of same canonical url are queued once
+func newCrawlState(seeds []string) *CrawlState {
+	state := &CrawlState{Seen: map[string]bool{}, HostPages: map[string]int{}}
+	for _, seed := range seeds {
+		key, err := utils.CanonicalizeUrl(seed)
+		if err != nil || state.Seen[key] {
+			continue
+		}
+		state.Seen[key] = true
+		state.Queue = append(state.Queue, CrawlStateItem{Url: strings.TrimSpace(seed), Depth: 0})
+	}
+	return state
+}
+
+// CrawlSite crawl seeds then follow links breadth-first, emit is called with record of every crawled page
+func CrawlSite(ctx context.Context, config SiteCrawlConfig, options CrawlOptions, emit func(model.CrawlRecord)) error {
+	return crawlSite(ctx, config, options, newCrawlState(config.Seeds), emit, nil)
+}
+
+// crawlSite continue crawl of state, afterPage is called after every crawled page (ex: saving checkpoint)
+func crawlSite(ctx context.Context, config SiteCrawlConfig, options CrawlOptions, state *CrawlState, emit func(model.CrawlRecord), afterPage func(state *CrawlState)) error {
+	scope, err := newSiteScope(config)
+	if err != nil {
+		return err
+	}
+	options.CollectLinks = true
+
+	for len(state.Queue) > 0 {
+		if config.MaxPages > 0 && state.Pages >= config.MaxPages {
+			break
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		item := state.Queue[0]
+		host := crawlStateHost(utils.CanonicalUrlOrRaw(item.Url))
+		if config.MaxPagesPerHost > 0 && state.HostPages[host] >= config.MaxPagesPerHost {
+			state.Queue = state.Queue[1:]
+			continue
+		}
+
+		record := model.CrawlRecord{Url: item.Url, Depth: item.Depth}
+		openGraphModel, err := Crawl(ctx, item.Url, options)
+		if err != nil && ctx.Err() != nil {
+			// keep item in queue, it is crawled again when resuming
+			return ctx.Err()
+		}
+		state.Queue = state.Queue[1:]
+		state.Pages++
+		state.HostPages[host]++
+		if err != nil {
+			log.Println("crawl error:", item.Url, err)
+			record.Error = err.Error()
+			emit(record)
+		} else {
+			record.Result = &openGraphModel
+			emit(record)
+
+			if item.Depth < config.MaxDepth {
+				for _, link := range openGraphModel.Links {
+					key, err := utils.CanonicalizeUrl(link)
+					if err != nil || state.Seen[key] || !scope.contains(key) {
+						continue
+					}
+					state.Seen[key] = true
+					state.Queue = append(state.Queue, CrawlStateItem{Url: link, Depth: item.Depth + 1})
+				}
+			}
+		}
+		if afterPage != nil {
+			afterPage(state)
+		}
+	}
+	return nil
+}
+
+func crawlStateHost(rawUrl string) string {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
+func newSiteScope(config SiteCrawlConfig) (scope *siteScope, err error) {
+	if len(config.Seeds) == 0 {
+		return nil, errors.New("seeds is EMPTY")
+	}
+	scope = &siteScope{config: config, hosts: map[string]bool{}}
+	for _, seed := range config.Seeds {
+		u, err := url.Parse(strings.TrimSpace(seed))
+		if err != nil {
+			return nil, err
+		}
+		host := strings.ToLower(u.Hostname())
+		if config.Scope == SCOPE_DOMAIN {
+			host = strings.TrimPrefix(host, "www.")
+		}
+		scope.hosts[host] = true
+	}
+	if config.IncludePattern != "" {
+		if scope.include, err = regexp.Compile(config.IncludePattern); err != nil {
+			return nil, err
+		}
+	}
+	if config.ExcludePattern != "" {
+		if scope.exclude, err = regexp.Compile(config.ExcludePattern); err != nil {
+			return nil, err
+		}
+	}
+	return scope, nil
+}
+
+func (scope *siteScope) contains(link string) bool {
+	u, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	switch scope.config.Scope {
+	case SCOPE_ANY:
+	case SCOPE_DOMAIN:
+		matched := false
+		for domain := range scope.hosts {
+			if host == domain || strings.HasSuffix(host, "."+domain) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	default:
+		if !scope.hosts[host] {
+			return false
+		}
+	}
+	if scope.config.PathPrefix != "" && !strings.HasPrefix(u.Path, scope.config.PathPrefix) {
+		return false
+	}
+	if scope.include != nil && !scope.include.MatchString(link) {
+		return false
+	}
+	if scope.exclude != nil && scope.exclude.MatchString(link) {
+		return false
+	}
+	return true
+}
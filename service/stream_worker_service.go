@@ -0,0 +1,442 @@
+package service
+
+import (
+	"context"
+	"crawlweb/infrastructure"
+	"crawlweb/model"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// type of stream job
+const (
+	STREAM_JOB_CRAWL  = "crawl"
+	STREAM_JOB_UPLOAD = "upload"
+)
+
+// status of job in result stream
+const (
+	STREAM_JOB_SUCCEEDED = "succeeded"
+	STREAM_JOB_FAILED    = "failed"
+)
+
+// StreamWorkerConfig consumer of jobs in redis stream. Job is acked when it succeed,
+// failed job stays pending and is claimed again after ClaimIdle until it is delivered MaxDeliveries times,
+// then it is moved to DeadLetterStream. Result of job (succeeded or dead) is added to ResultStream
+type StreamWorkerConfig struct {
+	Stream           string
+	Group            string
+	Consumer         string
+	ResultStream     string
+	DeadLetterStream string
+	// Workers number of jobs processed at same time
+	Workers       int
+	MaxDeliveries int64
+	JobTimeout    time.Duration
+	// ClaimIdle pending job of this or other consumer (failed or crashed) is claimed after this idle time, longer than JobTimeout
+	ClaimIdle time.Duration
+	// Block wait time of reading new jobs
+	Block time.Duration
+	// ResultMaxLen approximate max length of result and dead letter streams, 0 is unlimited
+	ResultMaxLen int64
+}
+
+var DefaultStreamWorkerConfig = StreamWorkerConfig{
+	Stream:           "crawlweb:jobs",
+	Group:            "crawlweb",
+	ResultStream:     "crawlweb:results",
+	DeadLetterStream: "crawlweb:jobs:dead",
+	Workers:          4,
+	MaxDeliveries:    5,
+	JobTimeout:       2 * time.Minute,
+	ClaimIdle:        3 * time.Minute,
+	Block:            5 * time.Second,
+	ResultMaxLen:     100000,
+}
+
+// StreamClaimInterval how often pending jobs are checked for claiming
+var StreamClaimInterval = 15 * time.Second
+
+var ErrInvalidStreamJob = errors.New("invalid stream job")
+
+type streamMessage struct {
+	message    redis.XMessage
+	deliveries int64
+}
+
+type streamWorker struct {
+	config StreamWorkerConfig
+	client *redis.Client
+	// wg jobs and webhooks running in background
+	wg sync.WaitGroup
+}
+
+// EnqueueStreamJob add job to stream, return id of message
+func EnqueueStreamJob(ctx context.Context, stream string, job model.StreamJob) (messageId string, err error) {
+	if job.Type == "" {
+		job.Type = STREAM_JOB_CRAWL
+	}
+	values := map[string]interface{}{
+		"type": job.Type,
+		"url":  job.Url,
+	}
+	if job.JobId != "" {
+		values["job_id"] = job.JobId
+	}
+	if job.Storage != "" {
+		values["storage"] = job.Storage
+	}
+	if job.SkipUpload {
+		values["skip_upload"] = "true"
+	}
+	redisClient, err := infrastructure.GetRedisClient()
+	if err != nil {
+		return
+	}
+	return redisClient.XAdd(ctx, &redis.XAddArgs{Stream: stream, Values: values}).Result()
+}
+
+// RunStreamWorker consume jobs until ctx is done, then wait running jobs
+func RunStreamWorker(ctx context.Context, config StreamWorkerConfig) error {
+	if config.Consumer == "" {
+		hostname, _ := os.Hostname()
+		config.Consumer = fmt.Sprintf("%s-%d", hostname, os.Getpid())
+	}
+	if config.Workers < 1 {
+		config.Workers = 1
+	}
+	if config.MaxDeliveries < 1 {
+		config.MaxDeliveries = 1
+	}
+	if config.ClaimIdle <= config.JobTimeout {
+		// job is not claimed by other consumer while it is running
+		config.ClaimIdle = config.JobTimeout + 30*time.Second
+	}
+	redisClient, err := infrastructure.GetRedisClient()
+	if err != nil {
+		return err
+	}
+	worker := &streamWorker{config: config, client: redisClient}
+	err = worker.client.XGroupCreateMkStream(ctx, config.Stream, config.Group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		log.Println("create consumer group error:", err)
+		return err
+	}
+	log.Printf("consumer %s of group %s is reading stream %s\n", config.Consumer, config.Group, config.Stream)
+
+	slots := make(chan struct{}, config.Workers)
+	var lastClaim time.Time
+	for {
+		// wait for free worker, then take all free workers
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			worker.wg.Wait()
+			return nil
+		}
+		free := 1
+		for acquired := true; acquired && free < config.Workers; {
+			select {
+			case slots <- struct{}{}:
+				free++
+			default:
+				acquired = false
+			}
+		}
+
+		var messages []streamMessage
+		if time.Since(lastClaim) >= StreamClaimInterval {
+			lastClaim = time.Now()
+			if messages, err = worker.claim(ctx, free); err != nil {
+				log.Println("claim pending jobs error:", err)
+			}
+		}
+		if len(messages) == 0 {
+			messages, err = worker.read(ctx, free)
+		}
+		for i := len(messages); i < free; i++ {
+			<-slots
+		}
+		if err != nil && ctx.Err() == nil {
+			log.Println("read stream error:", err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Second):
+			}
+		}
+		for _, message := range messages {
+			worker.wg.Add(1)
+			go func(message streamMessage) {
+				defer worker.wg.Done()
+				defer func() { <-slots }()
+				worker.handle(message)
+			}(message)
+		}
+	}
+}
+
+// read new jobs of this consumer
+func (worker *streamWorker) read(ctx context.Context, count int) ([]streamMessage, error) {
+	streams, err := worker.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    worker.config.Group,
+		Consumer: worker.config.Consumer,
+		Streams:  []string{worker.config.Stream, ">"},
+		Count:    int64(count),
+		Block:    worker.config.Block,
+	}).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var messages []streamMessage
+	for _, stream := range streams {
+		for _, message := range stream.Messages {
+			messages = append(messages, streamMessage{message: message, deliveries: 1})
+		}
+	}
+	return messages, nil
+}
+
+// claim jobs pending longer than ClaimIdle, they are failed jobs or jobs of crashed consumer.
+// Idle filter of XPENDING (redis 6.2) skips jobs which are running, so idle jobs behind them are found
+func (worker *streamWorker) claim(ctx context.Context, count int) ([]streamMessage, error) {
+	pending, err := worker.client.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream: worker.config.Stream,
+		Group:  worker.config.Group,
+		Idle:   worker.config.ClaimIdle,
+		Start:  "-",
+		End:    "+",
+		Count:  int64(count),
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	deliveries := make(map[string]int64)
+	for _, entry := range pending {
+		ids = append(ids, entry.ID)
+		deliveries[entry.ID] = entry.RetryCount + 1
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	// message claimed by other consumer at same time is not returned
+	claimed, err := worker.client.XClaim(ctx, &redis.XClaimArgs{
+		Stream:   worker.config.Stream,
+		Group:    worker.config.Group,
+		Consumer: worker.config.Consumer,
+		MinIdle:  worker.config.ClaimIdle,
+		Messages: ids,
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	messages := make([]streamMessage, 0, len(claimed))
+	for _, message := range claimed {
+		messages = append(messages, streamMessage{message: message, deliveries: deliveries[message.ID]})
+	}
+	return messages, nil
+}
+
+// handle run job, ack it with its result, or leave it pending for retry, or move it to dead letter stream
+func (worker *streamWorker) handle(message streamMessage) {
+	defer func() {
+		// job stays pending, it is retried after ClaimIdle until MaxDeliveries
+		if r := recover(); r != nil {
+			log.Printf("panic in job %s: %v\n%s", message.message.ID, r, debug.Stack())
+		}
+	}()
+	job, err := parseStreamJob(message.message)
+	job.Deliveries = message.deliveries
+	if err != nil {
+		worker.deadLetter(message.message, job, err)
+		return
+	}
+	if job.Deliveries > worker.config.MaxDeliveries {
+		// job crashed consumers before its result could be saved
+		worker.deadLetter(message.message, job, fmt.Errorf("delivered %d times, max is %d", job.Deliveries, worker.config.MaxDeliveries))
+		return
+	}
+
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), worker.config.JobTimeout)
+	defer cancel()
+	result, err := runStreamJob(ctx, job)
+	duration := time.Since(start)
+	if err != nil {
+		if isPermanentStreamError(err) || job.Deliveries >= worker.config.MaxDeliveries {
+			worker.deadLetter(message.message, job, err)
+			return
+		}
+		log.Printf("job %s failed (delivery %d of %d), it is retried after %v: %v\n",
+			job.MessageId, job.Deliveries, worker.config.MaxDeliveries, worker.config.ClaimIdle, err)
+		return
+	}
+
+	data, _ := json.Marshal(result)
+	values := worker.resultValues(job, STREAM_JOB_SUCCEEDED, "")
+	values["result"] = string(data)
+	values["duration_ms"] = duration.Milliseconds()
+	if err := worker.publish(values, nil, job.MessageId); err != nil {
+		// job stays pending, it is done again after ClaimIdle
+		log.Println("publish result of job", job.MessageId, "error:", err)
+		return
+	}
+	switch result := result.(type) {
+	case model.OpenGraphModel:
+		worker.notifyWebhook(WEBHOOK_CRAWL_COMPLETED, model.CrawlEventData{Url: job.Url, Result: &result})
+	case model.UploadEventData:
+		worker.notifyWebhook(WEBHOOK_UPLOAD_COMPLETED, result)
+	}
+}
+
+// deadLetter move job to dead letter stream and add failed result
+func (worker *streamWorker) deadLetter(message redis.XMessage, job model.StreamJob, errJob error) {
+	log.Printf("job %s is moved to %s: %v\n", message.ID, worker.config.DeadLetterStream, errJob)
+	deadValues := make(map[string]interface{}, len(message.Values)+5)
+	for key, value := range message.Values {
+		deadValues[key] = value
+	}
+	deadValues["original_id"] = message.ID
+	deadValues["error"] = errJob.Error()
+	deadValues["deliveries"] = job.Deliveries
+	deadValues["consumer"] = worker.config.Consumer
+	deadValues["failed_time"] = time.Now().Unix()
+	if err := worker.publish(worker.resultValues(job, STREAM_JOB_FAILED, errJob.Error()), deadValues, message.ID); err != nil {
+		log.Println("move job", message.ID, "to dead letter stream error:", err)
+		return
+	}
+	switch job.Type {
+	case STREAM_JOB_CRAWL:
+		worker.notifyWebhook(WEBHOOK_CRAWL_FAILED, model.CrawlEventData{Url: job.Url, Error: errJob.Error()})
+	case STREAM_JOB_UPLOAD:
+		worker.notifyWebhook(WEBHOOK_UPLOAD_FAILED, model.UploadEventData{Source: job.Url, Storage: job.Storage, Error: errJob.Error()})
+	}
+}
+
+// publish add result (and dead letter) then ack job in one transaction
+func (worker *streamWorker) publish(resultValues map[string]interface{}, deadValues map[string]interface{}, messageId string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := worker.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		if deadValues != nil {
+			pipe.XAdd(ctx, &redis.XAddArgs{Stream: worker.config.DeadLetterStream, MaxLenApprox: worker.config.ResultMaxLen, Values: deadValues})
+		}
+		pipe.XAdd(ctx, &redis.XAddArgs{Stream: worker.config.ResultStream, MaxLenApprox: worker.config.ResultMaxLen, Values: resultValues})
+		pipe.XAck(ctx, worker.config.Stream, worker.config.Group, messageId)
+		return nil
+	})
+	return err
+}
+
+func (worker *streamWorker) resultValues(job model.StreamJob, status string, errJob string) map[string]interface{} {
+	values := map[string]interface{}{
+		"message_id":    job.MessageId,
+		"type":          job.Type,
+		"url":           job.Url,
+		"status":        status,
+		"deliveries":    job.Deliveries,
+		"consumer":      worker.config.Consumer,
+		"finished_time": time.Now().Unix(),
+	}
+	if job.JobId != "" {
+		values["job_id"] = job.JobId
+	}
+	if errJob != "" {
+		values["error"] = errJob
+	}
+	return values
+}
+
+// notifyWebhook emit event in background, RunStreamWorker waits it before returning
+func (worker *streamWorker) notifyWebhook(eventType string, data interface{}) {
+	worker.wg.Add(1)
+	go func() {
+		defer worker.wg.Done()
+		if err := EmitWebhookEvent(context.Background(), eventType, data); err != nil {
+			log.Println("webhook", eventType, "error:", err)
+		}
+	}()
+}
+
+func runStreamJob(ctx context.Context, job model.StreamJob) (result interface{}, err error) {
+	switch job.Type {
+	case STREAM_JOB_CRAWL:
+		return Crawl(ctx, job.Url, CrawlOptions{SkipUpload: job.SkipUpload, Storage: job.Storage})
+	case STREAM_JOB_UPLOAD:
+		upload := model.UploadEventData{Source: job.Url, Storage: job.Storage}
+		if job.Storage == STORAGE_DRIVE {
+			if upload.Filename = CreateFileAndSave(job.Url); upload.Filename == "" {
+				return nil, fmt.Errorf("upload %s to drive fail", job.Url)
+			}
+			return upload, nil
+		}
+		upload.Storage = STORAGE_S3
+		upload.Filename, upload.Etag, _, _, err = UploadFileToBucket(job.Url, "")
+		if err != nil {
+			return nil, err
+		}
+		return upload, nil
+	}
+	return nil, fmt.Errorf("%w: unknown type %q", ErrInvalidStreamJob, job.Type)
+}
+
+// parseStreamJob job of message fields: type (crawl or upload), url, job_id, storage, skip_upload
+func parseStreamJob(message redis.XMessage) (job model.StreamJob, err error) {
+	field := func(name string) string {
+		value, _ := message.Values[name].(string)
+		return strings.TrimSpace(value)
+	}
+	job = model.StreamJob{
+		MessageId: message.ID,
+		JobId:     field("job_id"),
+		Type:      field("type"),
+		Url:       field("url"),
+		Storage:   field("storage"),
+	}
+	if job.Type == "" {
+		job.Type = STREAM_JOB_CRAWL
+	}
+	if job.Type != STREAM_JOB_CRAWL && job.Type != STREAM_JOB_UPLOAD {
+		return job, fmt.Errorf("%w: unknown type %q", ErrInvalidStreamJob, job.Type)
+	}
+	u, errUrl := url.Parse(job.Url)
+	if errUrl != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return job, fmt.Errorf("%w: url must be absolute http or https url", ErrInvalidStreamJob)
+	}
+	switch job.Storage {
+	case "", STORAGE_S3, STORAGE_DRIVE:
+	case STORAGE_NONE:
+		if job.Type == STREAM_JOB_UPLOAD {
+			return job, fmt.Errorf("%w: storage of upload job can not be none", ErrInvalidStreamJob)
+		}
+	default:
+		return job, fmt.Errorf("%w: invalid storage %q", ErrInvalidStreamJob, job.Storage)
+	}
+	if skipUpload := field("skip_upload"); skipUpload != "" {
+		if job.SkipUpload, err = strconv.ParseBool(skipUpload); err != nil {
+			return job, fmt.Errorf("%w: skip_upload must be true or false", ErrInvalidStreamJob)
+		}
+	}
+	return job, nil
+}
+
+// isPermanentStreamError error which is same on every delivery, job is not retried
+func isPermanentStreamError(err error) bool {
+	return errors.Is(err, ErrInvalidStreamJob) || errors.Is(err, infrastructure.ErrUrlNotAllowed) ||
+		errors.Is(err, ErrContentTypeNotAllowed) || errors.Is(err, ErrBodyTooLarge) ||
+		errors.Is(err, ErrRedirectLoop) || errors.Is(err, ErrTooManyRedirects)
+}
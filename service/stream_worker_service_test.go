@@ -0,0 +1,47 @@
+package service
+
+import (
+	"crawlweb/model"
+	"errors"
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+)
+
+func TestParseStreamJob(t *testing.T) {
+	tests := []struct {
+		values  map[string]interface{}
+		want    model.StreamJob
+		wantErr bool
+	}{
+		{map[string]interface{}{"url": "https://example.com/a"},
+			model.StreamJob{MessageId: "1-0", Type: STREAM_JOB_CRAWL, Url: "https://example.com/a"}, false},
+		{map[string]interface{}{"type": "upload", "url": " https://example.com/a.png ", "job_id": "j1", "storage": "drive"},
+			model.StreamJob{MessageId: "1-0", JobId: "j1", Type: STREAM_JOB_UPLOAD, Url: "https://example.com/a.png", Storage: STORAGE_DRIVE}, false},
+		{map[string]interface{}{"type": "crawl", "url": "https://example.com/a", "storage": "none", "skip_upload": "true"},
+			model.StreamJob{MessageId: "1-0", Type: STREAM_JOB_CRAWL, Url: "https://example.com/a", Storage: STORAGE_NONE, SkipUpload: true}, false},
+		{map[string]interface{}{"type": "delete", "url": "https://example.com/a"}, model.StreamJob{}, true},
+		{map[string]interface{}{}, model.StreamJob{}, true},
+		{map[string]interface{}{"url": "example.com/a"}, model.StreamJob{}, true},
+		{map[string]interface{}{"url": "file:///etc/passwd"}, model.StreamJob{}, true},
+		{map[string]interface{}{"url": "https://example.com/a", "storage": "ftp"}, model.StreamJob{}, true},
+		{map[string]interface{}{"type": "upload", "url": "https://example.com/a.png", "storage": "none"}, model.StreamJob{}, true},
+		{map[string]interface{}{"url": "https://example.com/a", "skip_upload": "maybe"}, model.StreamJob{}, true},
+	}
+	for _, test := range tests {
+		got, err := parseStreamJob(redis.XMessage{ID: "1-0", Values: test.values})
+		if test.wantErr {
+			if !errors.Is(err, ErrInvalidStreamJob) {
+				t.Errorf("parseStreamJob(%v) error = %v, want ErrInvalidStreamJob", test.values, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseStreamJob(%v) error: %v", test.values, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("parseStreamJob(%v) = %+v, want %+v", test.values, got, test.want)
+		}
+	}
+}
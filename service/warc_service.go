@@ -0,0 +1,30 @@
+package service
+
+import (
+	"crawlweb/infrastructure"
+	"log"
+	"os"
+)
+
+func init() {
+	infrastructure.OnWarcFileClosed = UploadWarcFile
+}
+
+// UploadWarcFile upload finished WARC file to bucket using multipart upload, if it is enabled in config
+func UploadWarcFile(filePath string) {
+	if !infrastructure.GetFetcherConfig().Warc.UploadToS3 {
+		return
+	}
+	file, err := os.Open(filePath)
+	if err != nil {
+		log.Println("open warc file error:", err)
+		return
+	}
+	defer file.Close()
+	_, _, err = UploadLargeFileUsingPresignedUrl(file)
+	if err != nil {
+		log.Println("upload warc file error:", filePath, err)
+		return
+	}
+	log.Println("uploaded warc file:", filePath)
+}
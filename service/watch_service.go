@@ -0,0 +1,283 @@
+package service
+
+import (
+	"context"
+	"crawlweb/infrastructure"
+	"crawlweb/model"
+	"crawlweb/utils"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	// WatchPollInterval how often due urls of watch list are checked
+	WatchPollInterval = 30 * time.Second
+	// WatchBatchSize max urls claimed in one poll
+	WatchBatchSize   = 20
+	WatchMinInterval = time.Minute
+)
+
+// watched fields, change of them emit diff event
+var watchFields = []string{"title", "description", "image", "price"}
+
+// AddWatch add url to watch list or update interval of watched url, url is crawled at next poll.
+// Urls of same canonical url are one watch
+func AddWatch(ctx context.Context, url string, interval time.Duration) (watch model.WatchUrl, err error) {
+	if interval < WatchMinInterval {
+		interval = WatchMinInterval
+	}
+	now := time.Now().Unix()
+	watch = model.WatchUrl{
+		Url:             strings.TrimSpace(url),
+		UrlHash:         watchUrlHash(url),
+		IntervalSeconds: int64(interval.Seconds()),
+		NextCrawlTime:   now,
+		CreatedTime:     now,
+		UpdateTime:      now,
+	}
+	db, err := infrastructure.GetDB()
+	if err != nil {
+		return
+	}
+	_, err = db.NamedExecContext(ctx, `INSERT INTO watch_urls (url, url_hash, interval_seconds, next_crawl_time, created_time, updated_time)
+		VALUES (:url, :url_hash, :interval_seconds, :next_crawl_time, :created_time, :updated_time)
+		ON DUPLICATE KEY UPDATE interval_seconds = VALUES(interval_seconds), updated_time = VALUES(updated_time)`, &watch)
+	if err != nil {
+		log.Println("add watch error:", err)
+		return
+	}
+	err = db.GetContext(ctx, &watch, `SELECT * FROM watch_urls WHERE url_hash = ?`, watch.UrlHash)
+	return
+}
+
+// RemoveWatch remove url and its history from watch list
+func RemoveWatch(ctx context.Context, url string) error {
+	db, err := infrastructure.GetDB()
+	if err != nil {
+		return err
+	}
+	watch := model.WatchUrl{}
+	err = db.GetContext(ctx, &watch, `SELECT * FROM watch_urls WHERE url_hash = ?`, watchUrlHash(url))
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, query := range []string{
+		`DELETE FROM watch_changes WHERE watch_id = ?`,
+		`DELETE FROM watch_versions WHERE watch_id = ?`,
+		`DELETE FROM watch_urls WHERE id = ?`,
+	} {
+		if _, err := db.ExecContext(ctx, query, watch.Id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func ListWatches(ctx context.Context) (watches []model.WatchUrl, err error) {
+	db, err := infrastructure.GetDB()
+	if err != nil {
+		return
+	}
+	err = db.SelectContext(ctx, &watches, `SELECT * FROM watch_urls ORDER BY id`)
+	return
+}
+
+// GetWatchHistory versions of watched url, newest first
+func GetWatchHistory(ctx context.Context, watchId int64) (versions []model.WatchVersion, err error) {
+	db, err := infrastructure.GetDB()
+	if err != nil {
+		return
+	}
+	err = db.SelectContext(ctx, &versions, `SELECT * FROM watch_versions WHERE watch_id = ? ORDER BY id DESC`, watchId)
+	return
+}
+
+// RunWatcher re-crawl due urls of watch list until ctx is done. Many watchers can run at same time,
+// each due url is claimed by one of them
+func RunWatcher(ctx context.Context, options CrawlOptions, emit func(model.WatchChangeEvent)) error {
+	ticker := time.NewTicker(WatchPollInterval)
+	defer ticker.Stop()
+	for {
+		watches, err := claimDueWatches(ctx)
+		if err != nil {
+			log.Println("claim watch error:", err)
+		}
+		for _, watch := range watches {
+			if err := CheckWatch(ctx, watch, options, emit); err != nil {
+				log.Println("check watch error:", watch.Url, err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// claimDueWatches due urls, next crawl time is moved so other watchers skip them
+func claimDueWatches(ctx context.Context) (claimed []model.WatchUrl, err error) {
+	db, err := infrastructure.GetDB()
+	if err != nil {
+		return
+	}
+	now := time.Now().Unix()
+	watches := []model.WatchUrl{}
+	err = db.SelectContext(ctx, &watches, `SELECT * FROM watch_urls WHERE next_crawl_time <= ? ORDER BY next_crawl_time LIMIT ?`, now, WatchBatchSize)
+	if err != nil {
+		return
+	}
+	for _, watch := range watches {
+		result, err := db.ExecContext(ctx, `UPDATE watch_urls SET next_crawl_time = ? WHERE id = ? AND next_crawl_time = ?`,
+			now+watch.IntervalSeconds, watch.Id, watch.NextCrawlTime)
+		if err != nil {
+			return claimed, err
+		}
+		if rows, _ := result.RowsAffected(); rows == 1 {
+			claimed = append(claimed, watch)
+		}
+	}
+	return
+}
+
+// CheckWatch crawl watched url, store new version when content changed and emit diff of watched fields
+func CheckWatch(ctx context.Context, watch model.WatchUrl, options CrawlOptions, emit func(model.WatchChangeEvent)) error {
+	db, err := infrastructure.GetDB()
+	if err != nil {
+		return err
+	}
+	now := time.Now().Unix()
+	// cached page or result would hide changes of watched url
+	options.NoCache = true
+	openGraphModel, err := Crawl(ctx, watch.Url, options)
+	if err != nil {
+		_, errDb := db.ExecContext(ctx, `UPDATE watch_urls SET last_crawl_time = ?, last_error = ?, updated_time = ? WHERE id = ?`,
+			now, truncateString(err.Error(), 1024), now, watch.Id)
+		if errDb != nil {
+			log.Println("update watch error:", errDb)
+		}
+		return err
+	}
+
+	var last *model.WatchVersion
+	if watch.LastVersionId > 0 {
+		last = &model.WatchVersion{}
+		if err := db.GetContext(ctx, last, `SELECT * FROM watch_versions WHERE id = ?`, watch.LastVersionId); err != nil {
+			return err
+		}
+	}
+	snapshot, err := json.Marshal(openGraphModel)
+	if err != nil {
+		return err
+	}
+	version := model.WatchVersion{
+		WatchId:     watch.Id,
+		ContentHash: openGraphModel.ContentHash,
+		Title:       openGraphModel.Title,
+		Description: openGraphModel.Description,
+		Image:       openGraphModel.Image,
+		Price:       openGraphModel.Price,
+		Snapshot:    string(snapshot),
+		CreatedTime: now,
+	}
+	changes := diffWatchVersion(last, version)
+	if last != nil && len(changes) == 0 && last.ContentHash == version.ContentHash {
+		_, err = db.ExecContext(ctx, `UPDATE watch_urls SET last_crawl_time = ?, last_error = '', updated_time = ? WHERE id = ?`,
+			now, now, watch.Id)
+		return err
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	result, err := tx.NamedExecContext(ctx, `INSERT INTO watch_versions (watch_id, content_hash, title, description, image, price, snapshot, created_time)
+		VALUES (:watch_id, :content_hash, :title, :description, :image, :price, :snapshot, :created_time)`, &version)
+	if err != nil {
+		return err
+	}
+	if version.Id, err = result.LastInsertId(); err != nil {
+		return err
+	}
+	for _, change := range changes {
+		_, err = tx.ExecContext(ctx, `INSERT INTO watch_changes (watch_id, version_id, field, old_value, new_value, created_time) VALUES (?, ?, ?, ?, ?, ?)`,
+			watch.Id, version.Id, change.Field, change.OldValue, change.NewValue, now)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = tx.ExecContext(ctx, `UPDATE watch_urls SET last_crawl_time = ?, last_error = '', last_version_id = ?, updated_time = ? WHERE id = ?`,
+		now, version.Id, now, watch.Id)
+	if err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	if len(changes) > 0 && emit != nil {
+		emit(model.WatchChangeEvent{
+			WatchId:       watch.Id,
+			Url:           watch.Url,
+			VersionId:     version.Id,
+			PrevVersionId: last.Id,
+			Changes:       changes,
+			DetectedTime:  now,
+		})
+	}
+	return nil
+}
+
+// diffWatchVersion changes of watched fields, first version has no change
+func diffWatchVersion(last *model.WatchVersion, version model.WatchVersion) (changes []model.FieldChange) {
+	if last == nil {
+		return nil
+	}
+	for _, field := range watchFields {
+		oldValue, newValue := watchField(*last, field), watchField(version, field)
+		if oldValue != newValue {
+			changes = append(changes, model.FieldChange{Field: field, OldValue: oldValue, NewValue: newValue})
+		}
+	}
+	return
+}
+
+func watchField(version model.WatchVersion, field string) string {
+	switch field {
+	case "title":
+		return version.Title
+	case "description":
+		return version.Description
+	case "image":
+		return version.Image
+	case "price":
+		return version.Price
+	}
+	return ""
+}
+
+func watchUrlHash(url string) string {
+	sum := sha256.Sum256([]byte(utils.CanonicalUrlOrRaw(url)))
+	return hex.EncodeToString(sum[:])
+}
+
+// truncateString cut value to at most maxLength bytes, multi-byte character is not cut in half
+func truncateString(value string, maxLength int) string {
+	if len(value) <= maxLength {
+		return value
+	}
+	for maxLength > 0 && !utf8.RuneStart(value[maxLength]) {
+		maxLength--
+	}
+	return value[:maxLength]
+}
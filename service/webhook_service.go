@@ -0,0 +1,427 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crawlweb/infrastructure"
+	"crawlweb/model"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// webhook event types
+const (
+	WEBHOOK_CRAWL_COMPLETED      = "crawl.completed"
+	WEBHOOK_CRAWL_FAILED         = "crawl.failed"
+	WEBHOOK_BATCH_COMPLETED      = "batch.completed"
+	WEBHOOK_SITE_CRAWL_COMPLETED = "site_crawl.completed"
+	WEBHOOK_UPLOAD_COMPLETED     = "upload.completed"
+	WEBHOOK_UPLOAD_FAILED        = "upload.failed"
+)
+
+// status of webhook delivery
+const (
+	DELIVERY_PENDING   = "pending"
+	DELIVERY_SUCCEEDED = "succeeded"
+	DELIVERY_FAILED    = "failed"
+)
+
+// headers of webhook request, signature is hex of HMAC-SHA256(secret, timestamp + "." + body)
+const (
+	HEADER_WEBHOOK_EVENT     = "X-Crawlweb-Event"
+	HEADER_WEBHOOK_EVENT_ID  = "X-Crawlweb-Event-Id"
+	HEADER_WEBHOOK_DELIVERY  = "X-Crawlweb-Delivery"
+	HEADER_WEBHOOK_TIMESTAMP = "X-Crawlweb-Timestamp"
+	HEADER_WEBHOOK_SIGNATURE = "X-Crawlweb-Signature"
+)
+
+var WebhookEventTypes = []string{
+	WEBHOOK_CRAWL_COMPLETED, WEBHOOK_CRAWL_FAILED, WEBHOOK_BATCH_COMPLETED,
+	WEBHOOK_SITE_CRAWL_COMPLETED, WEBHOOK_UPLOAD_COMPLETED, WEBHOOK_UPLOAD_FAILED,
+}
+
+var (
+	// WebhooksEnabled events are emitted only when it is set, endpoints are read from database
+	WebhooksEnabled = false
+	// WebhookTimeout limit of one attempt
+	WebhookTimeout = 10 * time.Second
+	// WebhookRetry retry on network error and RetryStatuses, other non 2xx status fail delivery immediately
+	WebhookRetry = infrastructure.RetryPolicy{
+		MaxAttempts:   5,
+		BaseDelay:     2 * time.Second,
+		MaxDelay:      time.Minute,
+		RetryStatuses: []int{408, 425, 429, 500, 502, 503, 504},
+	}
+
+	ErrUnknownWebhookEvent = errors.New("unknown webhook event")
+	ErrWebhookNotFound     = errors.New("webhook not found")
+)
+
+var webhookClient = &http.Client{
+	Timeout: WebhookTimeout,
+	// redirect of webhook endpoint is not followed, body is not resent
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// AddWebhook add endpoint receiving events (all events when events is empty), secret is generated when it is empty
+func AddWebhook(ctx context.Context, endpointUrl string, secret string, events []string) (endpoint model.WebhookEndpoint, err error) {
+	u, err := url.Parse(endpointUrl)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return endpoint, fmt.Errorf("invalid webhook url %q", endpointUrl)
+	}
+	for _, event := range events {
+		if !containsString(WebhookEventTypes, event) {
+			return endpoint, fmt.Errorf("%w: %s", ErrUnknownWebhookEvent, event)
+		}
+	}
+	if secret == "" {
+		if secret, err = newWebhookSecret(); err != nil {
+			return
+		}
+	}
+	now := time.Now().Unix()
+	endpoint = model.WebhookEndpoint{
+		Url:         endpointUrl,
+		Secret:      secret,
+		Events:      strings.Join(events, ","),
+		CreatedTime: now,
+		UpdateTime:  now,
+	}
+	db, err := infrastructure.GetDB()
+	if err != nil {
+		return
+	}
+	result, err := db.NamedExecContext(ctx, `INSERT INTO webhook_endpoints (url, secret, events, created_time, updated_time)
+		VALUES (:url, :secret, :events, :created_time, :updated_time)`, &endpoint)
+	if err != nil {
+		log.Println("add webhook error:", err)
+		return
+	}
+	endpoint.Id, err = result.LastInsertId()
+	return
+}
+
+func RemoveWebhook(ctx context.Context, id int64) error {
+	db, err := infrastructure.GetDB()
+	if err != nil {
+		return err
+	}
+	result, err := db.ExecContext(ctx, `DELETE FROM webhook_endpoints WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+func ListWebhooks(ctx context.Context) (endpoints []model.WebhookEndpoint, err error) {
+	db, err := infrastructure.GetDB()
+	if err != nil {
+		return
+	}
+	err = db.SelectContext(ctx, &endpoints, `SELECT * FROM webhook_endpoints ORDER BY id`)
+	return
+}
+
+// ListWebhookDeliveries latest deliveries, all statuses when status is empty
+func ListWebhookDeliveries(ctx context.Context, status string, limit int) (deliveries []model.WebhookDelivery, err error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	db, err := infrastructure.GetDB()
+	if err != nil {
+		return
+	}
+	if status == "" {
+		err = db.SelectContext(ctx, &deliveries, `SELECT * FROM webhook_deliveries ORDER BY id DESC LIMIT ?`, limit)
+	} else {
+		err = db.SelectContext(ctx, &deliveries, `SELECT * FROM webhook_deliveries WHERE status = ? ORDER BY id DESC LIMIT ?`, status, limit)
+	}
+	return
+}
+
+// EmitWebhookEvent send event to endpoints subscribed to it when WebhooksEnabled and wait until all deliveries succeed or fail.
+// Every delivery is logged in webhook_deliveries, failed delivery can be sent again by ReplayWebhookDeliveries
+func EmitWebhookEvent(ctx context.Context, eventType string, data interface{}) error {
+	if !WebhooksEnabled {
+		return nil
+	}
+	endpoints, err := ListWebhooks(ctx)
+	if err != nil {
+		log.Println("list webhooks error:", err)
+		return err
+	}
+	var subscribed []model.WebhookEndpoint
+	for _, endpoint := range endpoints {
+		if endpoint.Events == "" || containsString(strings.Split(endpoint.Events, ","), eventType) {
+			subscribed = append(subscribed, endpoint)
+		}
+	}
+	if len(subscribed) == 0 {
+		return nil
+	}
+
+	event := model.WebhookEvent{
+		Id:          uuid.NewString(),
+		Type:        eventType,
+		CreatedTime: time.Now().Unix(),
+		Data:        data,
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	db, err := infrastructure.GetDB()
+	if err != nil {
+		return err
+	}
+	deliveries := make([]model.WebhookDelivery, 0, len(subscribed))
+	for _, endpoint := range subscribed {
+		now := time.Now().Unix()
+		delivery := model.WebhookDelivery{
+			EndpointId:  endpoint.Id,
+			EventId:     event.Id,
+			EventType:   eventType,
+			Payload:     string(payload),
+			Status:      DELIVERY_PENDING,
+			CreatedTime: now,
+			UpdateTime:  now,
+		}
+		result, err := db.NamedExecContext(ctx, `INSERT INTO webhook_deliveries
+			(endpoint_id, event_id, event_type, payload, status, created_time, updated_time)
+			VALUES (:endpoint_id, :event_id, :event_type, :payload, :status, :created_time, :updated_time)`, &delivery)
+		if err != nil {
+			log.Println("insert webhook delivery error:", err)
+			return err
+		}
+		delivery.Id, _ = result.LastInsertId()
+		deliveries = append(deliveries, delivery)
+	}
+	return deliverAll(ctx, subscribed, deliveries)
+}
+
+// ReplayWebhookDeliveries send deliveries of ids again. When ids is empty, all failed deliveries and
+// pending deliveries older than retry window (process stopped while retrying) are sent.
+// Payload and event id are same as first delivery, so receiver can ignore duplicated event
+func ReplayWebhookDeliveries(ctx context.Context, ids []int64) (replayed int, err error) {
+	var deliveries []model.WebhookDelivery
+	db, err := infrastructure.GetDB()
+	if err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		staleTime := time.Now().Add(-webhookRetryWindow()).Unix()
+		err = db.SelectContext(ctx, &deliveries, `SELECT * FROM webhook_deliveries WHERE status = ? OR (status = ? AND updated_time < ?) ORDER BY id`,
+			DELIVERY_FAILED, DELIVERY_PENDING, staleTime)
+	} else {
+		for _, id := range ids {
+			delivery := model.WebhookDelivery{}
+			if err = db.GetContext(ctx, &delivery, `SELECT * FROM webhook_deliveries WHERE id = ?`, id); err != nil {
+				return 0, fmt.Errorf("get delivery %d: %w", id, err)
+			}
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	if err != nil || len(deliveries) == 0 {
+		return 0, err
+	}
+
+	endpoints, err := ListWebhooks(ctx)
+	if err != nil {
+		return 0, err
+	}
+	endpointById := make(map[int64]model.WebhookEndpoint, len(endpoints))
+	for _, endpoint := range endpoints {
+		endpointById[endpoint.Id] = endpoint
+	}
+	var replayEndpoints []model.WebhookEndpoint
+	var replayDeliveries []model.WebhookDelivery
+	for _, delivery := range deliveries {
+		endpoint, ok := endpointById[delivery.EndpointId]
+		if !ok {
+			log.Printf("skip delivery %d, webhook %d is removed\n", delivery.Id, delivery.EndpointId)
+			continue
+		}
+		replayEndpoints = append(replayEndpoints, endpoint)
+		replayDeliveries = append(replayDeliveries, delivery)
+	}
+	return len(replayDeliveries), deliverAll(ctx, replayEndpoints, replayDeliveries)
+}
+
+// webhookRetryWindow longest time of all attempts of a delivery, pending delivery older than it is not retried anymore
+func webhookRetryWindow() time.Duration {
+	maxAttempts := WebhookRetry.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	return time.Duration(maxAttempts) * (WebhookTimeout + WebhookRetry.MaxDelay)
+}
+
+// deliverAll deliver to each endpoint concurrently, return first error
+func deliverAll(ctx context.Context, endpoints []model.WebhookEndpoint, deliveries []model.WebhookDelivery) error {
+	errs := make([]error, len(deliveries))
+	var wg sync.WaitGroup
+	for i := range deliveries {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = deliverWebhook(ctx, endpoints[i], &deliveries[i])
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deliverWebhook post payload with retry, result of each attempt is saved to delivery log
+func deliverWebhook(ctx context.Context, endpoint model.WebhookEndpoint, delivery *model.WebhookDelivery) error {
+	maxAttempts := WebhookRetry.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	for attempt := 1; ; attempt++ {
+		status, err := postWebhook(ctx, endpoint, delivery)
+		delivery.Attempts++
+		delivery.ResponseStatus = status
+		delivery.LastAttemptTime = time.Now().Unix()
+		delivery.LastError = ""
+		if err != nil {
+			delivery.LastError = truncateString(err.Error(), 1024)
+		}
+		retryable := err != nil && (status == 0 || isWebhookRetryStatus(status))
+		switch {
+		case err == nil:
+			delivery.Status = DELIVERY_SUCCEEDED
+		case retryable && attempt < maxAttempts && ctx.Err() == nil:
+			delivery.Status = DELIVERY_PENDING
+		default:
+			delivery.Status = DELIVERY_FAILED
+		}
+		if errSave := saveWebhookDelivery(delivery); errSave != nil {
+			log.Println("save webhook delivery error:", errSave)
+		}
+		if delivery.Status != DELIVERY_PENDING {
+			if err != nil {
+				log.Printf("webhook delivery %d to %s failed: %v\n", delivery.Id, endpoint.Url, err)
+			}
+			return err
+		}
+
+		delay := backoffDelay(WebhookRetry, attempt)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			delivery.Status = DELIVERY_FAILED
+			delivery.LastError = truncateString(ctx.Err().Error(), 1024)
+			if errSave := saveWebhookDelivery(delivery); errSave != nil {
+				log.Println("save webhook delivery error:", errSave)
+			}
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// postWebhook one attempt, status is 0 when request is not sent or has no response
+func postWebhook(ctx context.Context, endpoint model.WebhookEndpoint, delivery *model.WebhookDelivery) (status int, err error) {
+	ctx, cancel := context.WithTimeout(ctx, WebhookTimeout)
+	defer cancel()
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.Url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "crawlweb-webhook/1.0")
+	req.Header.Set(HEADER_WEBHOOK_EVENT, delivery.EventType)
+	req.Header.Set(HEADER_WEBHOOK_EVENT_ID, delivery.EventId)
+	req.Header.Set(HEADER_WEBHOOK_DELIVERY, strconv.FormatInt(delivery.Id, 10))
+	req.Header.Set(HEADER_WEBHOOK_TIMESTAMP, timestamp)
+	req.Header.Set(HEADER_WEBHOOK_SIGNATURE, "sha256="+SignWebhookPayload(endpoint.Secret, timestamp, body))
+
+	res, err := webhookClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("webhook response status %d", res.StatusCode)
+	}
+	return res.StatusCode, nil
+}
+
+// SignWebhookPayload hex of HMAC-SHA256(secret, timestamp + "." + body), receiver compute it again for verifying request
+func SignWebhookPayload(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func saveWebhookDelivery(delivery *model.WebhookDelivery) error {
+	// saved even when ctx of delivery is canceled
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	delivery.UpdateTime = time.Now().Unix()
+	db, err := infrastructure.GetDB()
+	if err != nil {
+		return err
+	}
+	_, err = db.NamedExecContext(ctx, `UPDATE webhook_deliveries SET status = :status, attempts = :attempts,
+		response_status = :response_status, last_error = :last_error, last_attempt_time = :last_attempt_time, updated_time = :updated_time
+		WHERE id = :id`, delivery)
+	return err
+}
+
+func newWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(secret), nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func isWebhookRetryStatus(status int) bool {
+	for _, retryStatus := range WebhookRetry.RetryStatuses {
+		if status == retryStatus {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,23 @@
+package service
+
+import "testing"
+
+func TestSignWebhookPayload(t *testing.T) {
+	body := []byte(`{"event":"crawl.completed"}`)
+	tests := []struct {
+		secret    string
+		timestamp string
+		body      []byte
+		want      string
+	}{
+		{"secret", "1700000000", body, "ff13e73d7b0b4a7d0c3e2447a9cc982aabb7c2468b87bf40c7382b6b56002160"},
+		{"other", "1700000000", body, "1a6516ad5df56ffd34a806f9dbbd8ae858c941ffd1aad679d3476c42c856c501"},
+		{"secret", "1700000001", body, "24fdc9b2fd91b86b07bb86b73d11622bb321340c4073a712eb8e026a2d404c8b"},
+		{"", "1700000000", nil, "c1da1b6c6b8e9da7f4bbb90f7cab0820f271ad19ccbf80c88479c4e14f37d1c6"},
+	}
+	for _, test := range tests {
+		if got := SignWebhookPayload(test.secret, test.timestamp, test.body); got != test.want {
+			t.Errorf("SignWebhookPayload(%q, %q, %q) = %q, want %q", test.secret, test.timestamp, test.body, got, test.want)
+		}
+	}
+}
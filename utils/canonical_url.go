@@ -0,0 +1,166 @@
+package utils
+
+import (
+	"errors"
+	"net/url"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+var (
+	// BlockedQueryParams query params removed from canonical url, compared in lowercase
+	BlockedQueryParams = []string{"fbclid", "gclid", "dclid", "gbraid", "wbraid", "msclkid", "yclid", "mc_cid", "mc_eid", "igshid", "_ga", "_gl"}
+	// BlockedQueryParamPrefixes query params with these prefixes are removed (utm_source, utm_medium...)
+	BlockedQueryParamPrefixes = []string{"utm_"}
+	// StripTrailingSlash /path/ and /path are same page
+	StripTrailingSlash = true
+)
+
+var ErrInvalidUrl = errors.New("invalid url")
+
+var defaultPorts = map[string]string{"http": "80", "https": "443"}
+
+// CanonicalizeUrl canonical form of url for dedupe keys, hashes and cache keys (url is still fetched as it is given):
+// lowercase scheme and host, punycode host, no default port, no fragment, normalized percent-encoding,
+// sorted query without tracking params
+func CanonicalizeUrl(rawUrl string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(rawUrl))
+	if err != nil {
+		return "", err
+	}
+	if u.Host == "" || u.Opaque != "" {
+		return "", ErrInvalidUrl
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if strings.Contains(host, ":") {
+		// ipv6
+		host = "[" + host + "]"
+	} else if asciiHost, err := idna.Lookup.ToASCII(host); err == nil {
+		host = asciiHost
+	}
+	port := u.Port()
+	if port != "" && port != defaultPorts[u.Scheme] {
+		host += ":" + port
+	}
+	u.Host = host
+	u.Fragment, u.RawFragment = "", ""
+
+	escapedPath := normalizePercentEncoding(u.EscapedPath())
+	if escapedPath == "" {
+		escapedPath = "/"
+	} else if StripTrailingSlash && len(escapedPath) > 1 {
+		escapedPath = strings.TrimRight(escapedPath, "/")
+		if escapedPath == "" {
+			escapedPath = "/"
+		}
+	}
+	if u.Path, err = url.PathUnescape(escapedPath); err != nil {
+		return "", err
+	}
+	u.RawPath = escapedPath
+	u.RawQuery = canonicalQuery(u.RawQuery)
+	u.ForceQuery = false
+	return u.String(), nil
+}
+
+// CanonicalUrlOrRaw canonical url, raw url is returned when it can not be parsed
+func CanonicalUrlOrRaw(rawUrl string) string {
+	canonicalUrl, err := CanonicalizeUrl(rawUrl)
+	if err != nil {
+		return strings.TrimSpace(rawUrl)
+	}
+	return canonicalUrl
+}
+
+// canonicalQuery sorted query params without blocked params, order of values of same key is kept
+func canonicalQuery(rawQuery string) string {
+	type param struct {
+		key   string
+		value string
+		raw   string
+	}
+	params := []param{}
+	for _, pair := range strings.FieldsFunc(rawQuery, func(r rune) bool { return r == '&' || r == ';' }) {
+		pair = normalizePercentEncoding(pair)
+		key, value := pair, ""
+		if i := strings.Index(pair, "="); i >= 0 {
+			key, value = pair[:i], pair[i+1:]
+		}
+		decodedKey, err := url.QueryUnescape(key)
+		if err != nil {
+			decodedKey = key
+		}
+		if isBlockedQueryParam(decodedKey) {
+			continue
+		}
+		params = append(params, param{key: key, value: value, raw: pair})
+	}
+	sort.SliceStable(params, func(i, j int) bool {
+		return params[i].key < params[j].key
+	})
+	raws := make([]string, len(params))
+	for i, p := range params {
+		raws[i] = p.raw
+	}
+	return strings.Join(raws, "&")
+}
+
+func isBlockedQueryParam(key string) bool {
+	key = strings.ToLower(key)
+	for _, blocked := range BlockedQueryParams {
+		if key == blocked {
+			return true
+		}
+	}
+	for _, prefix := range BlockedQueryParamPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizePercentEncoding decode escaped unreserved characters and uppercase hex of other escapes
+func normalizePercentEncoding(s string) string {
+	if !strings.Contains(s, "%") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '%' && i+2 < len(s) && isHex(s[i+1]) && isHex(s[i+2]) {
+			c := unhex(s[i+1])<<4 | unhex(s[i+2])
+			if isUnreserved(c) {
+				b.WriteByte(c)
+			} else {
+				b.WriteByte('%')
+				b.WriteString(strings.ToUpper(s[i+1 : i+3]))
+			}
+			i += 2
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+func isUnreserved(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	}
+	return c - 'A' + 10
+}
@@ -0,0 +1,78 @@
+package utils
+
+import "testing"
+
+func TestCanonicalizeUrl(t *testing.T) {
+	tests := []struct {
+		rawUrl string
+		want   string
+	}{
+		{"https://example.com", "https://example.com/"},
+		{"  https://example.com/article  ", "https://example.com/article"},
+		{"HTTP://Example.COM/Path", "http://example.com/Path"},
+		{"https://example.com./a", "https://example.com/a"},
+		{"http://example.com:80/a", "http://example.com/a"},
+		{"https://example.com:443/a", "https://example.com/a"},
+		{"https://example.com:8443/a", "https://example.com:8443/a"},
+		{"http://example.com:443/a", "http://example.com:443/a"},
+		{"https://bücher.example/", "https://xn--bcher-kva.example/"},
+		{"https://[::1]:443/a", "https://[::1]/a"},
+		{"https://[::1]:8080/a", "https://[::1]:8080/a"},
+		{"https://example.com/a#section", "https://example.com/a"},
+		{"https://example.com/a/", "https://example.com/a"},
+		{"https://example.com/a//", "https://example.com/a"},
+		{"https://example.com/", "https://example.com/"},
+		{"https://example.com/%7euser/%e2%82%ac", "https://example.com/~user/%E2%82%AC"},
+		{"https://example.com/a%2Fb", "https://example.com/a%2Fb"},
+		{"https://example.com/a?b=2&a=1", "https://example.com/a?a=1&b=2"},
+		{"https://example.com/a?b=2&a=3&a=1", "https://example.com/a?a=3&a=1&b=2"},
+		{"https://example.com/a?utm_source=x&utm_medium=y&id=1", "https://example.com/a?id=1"},
+		{"https://example.com/a?fbclid=abc&GCLID=def", "https://example.com/a"},
+		{"https://example.com/a?", "https://example.com/a"},
+		{"https://example.com/a?q=%7e%2f", "https://example.com/a?q=~%2F"},
+	}
+	for _, test := range tests {
+		got, err := CanonicalizeUrl(test.rawUrl)
+		if err != nil {
+			t.Errorf("CanonicalizeUrl(%q) error: %v", test.rawUrl, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("CanonicalizeUrl(%q) = %q, want %q", test.rawUrl, got, test.want)
+		}
+	}
+}
+
+func TestCanonicalizeUrlInvalid(t *testing.T) {
+	for _, rawUrl := range []string{"", "/relative/path", "mailto:user@example.com", "http://[::1"} {
+		if got, err := CanonicalizeUrl(rawUrl); err == nil {
+			t.Errorf("CanonicalizeUrl(%q) = %q, want error", rawUrl, got)
+		}
+	}
+}
+
+func TestCanonicalizeUrlIdempotent(t *testing.T) {
+	for _, rawUrl := range []string{
+		"HTTP://Example.COM:80/a/b/?utm_source=x&b=2&a=1#frag",
+		"https://bücher.example/%7euser/",
+		"https://example.com/a?q=%7e%2f&b",
+	} {
+		once, err := CanonicalizeUrl(rawUrl)
+		if err != nil {
+			t.Fatalf("CanonicalizeUrl(%q) error: %v", rawUrl, err)
+		}
+		twice, err := CanonicalizeUrl(once)
+		if err != nil || twice != once {
+			t.Errorf("CanonicalizeUrl(%q) = %q, want %q", once, twice, once)
+		}
+	}
+}
+
+func TestCanonicalUrlOrRaw(t *testing.T) {
+	if got := CanonicalUrlOrRaw(" /relative "); got != "/relative" {
+		t.Errorf("CanonicalUrlOrRaw(%q) = %q, want %q", " /relative ", got, "/relative")
+	}
+	if got := CanonicalUrlOrRaw("https://Example.com/a/"); got != "https://example.com/a" {
+		t.Errorf("CanonicalUrlOrRaw(%q) = %q, want %q", "https://Example.com/a/", got, "https://example.com/a")
+	}
+}
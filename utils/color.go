@@ -0,0 +1,125 @@
+package utils
+
+import (
+	"fmt"
+	"image"
+	"sort"
+)
+
+const (
+	sampleSize      = 64
+	minColorDiff    = 48
+	minAlphaVisible = 0x8000
+)
+
+type colorBucket struct {
+	r, g, b, count int
+	// key 12 bits color of bucket, order of buckets with same count
+	key int
+}
+
+// Resample scale image down to fit in maxSize x maxSize (nearest neighbor)
+func Resample(img image.Image, maxSize int) *image.RGBA {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > maxSize || height > maxSize {
+		if width >= height {
+			height = height * maxSize / width
+			width = maxSize
+		} else {
+			width = width * maxSize / height
+			height = maxSize
+		}
+	}
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			srcX := bounds.Min.X + x*bounds.Dx()/width
+			srcY := bounds.Min.Y + y*bounds.Dy()/height
+			dst.Set(x, y, img.At(srcX, srcY))
+		}
+	}
+	return dst
+}
+
+// ExtractColors return dominant color and palette (hex string, ex: #1a2b3c) of image
+func ExtractColors(img image.Image, paletteSize int) (dominant string, palette []string) {
+	small := Resample(img, sampleSize)
+	buckets := map[int]*colorBucket{}
+	for y := 0; y < small.Rect.Dy(); y++ {
+		for x := 0; x < small.Rect.Dx(); x++ {
+			r, g, b, a := small.At(x, y).RGBA()
+			// skip transparent pixel
+			if a < minAlphaVisible {
+				continue
+			}
+			r, g, b = r>>8, g>>8, b>>8
+			// 4 bits per channel
+			key := int(r>>4)<<8 | int(g>>4)<<4 | int(b>>4)
+			bucket, ok := buckets[key]
+			if !ok {
+				bucket = &colorBucket{key: key}
+				buckets[key] = bucket
+			}
+			bucket.r += int(r)
+			bucket.g += int(g)
+			bucket.b += int(b)
+			bucket.count++
+		}
+	}
+	if len(buckets) == 0 {
+		return
+	}
+
+	listBucket := make([]*colorBucket, 0, len(buckets))
+	for _, v := range buckets {
+		listBucket = append(listBucket, v)
+	}
+	// map order is random, buckets with same count are sorted by color so palette is same for same image
+	sort.Slice(listBucket, func(i, j int) bool {
+		if listBucket[i].count != listBucket[j].count {
+			return listBucket[i].count > listBucket[j].count
+		}
+		return listBucket[i].key < listBucket[j].key
+	})
+
+	chosen := []colorBucket{}
+	for _, v := range listBucket {
+		if len(chosen) >= paletteSize {
+			break
+		}
+		avg := colorBucket{r: v.r / v.count, g: v.g / v.count, b: v.b / v.count}
+		// skip color too close to the chosen ones
+		similar := false
+		for _, c := range chosen {
+			if colorDistance(c, avg) < minColorDiff {
+				similar = true
+				break
+			}
+		}
+		if similar {
+			continue
+		}
+		chosen = append(chosen, avg)
+		palette = append(palette, fmt.Sprintf("#%02x%02x%02x", avg.r, avg.g, avg.b))
+	}
+	dominant = palette[0]
+	return
+}
+
+func colorDistance(c1, c2 colorBucket) int {
+	return abs(c1.r-c2.r) + abs(c1.g-c2.g) + abs(c1.b-c2.b)
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}